@@ -1,49 +1,387 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add",
+	Use:   "add [\"Title amount @day #category\"]",
 	Short: "Add a new expense",
-	Run: func(cmd *cobra.Command, _ []string) {
+	Long: `Add a new expense, either with flags or in a quick form such as
+  monke add "Coffee 3.50 @15 #Food"
+where the number is the amount, @N the day, #X the category and the
+remaining words the title.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireWritable(cmd)
+
+		if jsonStdin, _ := cmd.Flags().GetBool("json-stdin"); jsonStdin {
+			addFromJSON(os.Stdin)
+			return
+		}
+
 		title, _ := cmd.Flags().GetString("title")
-		amount, _ := cmd.Flags().GetFloat64("amount")
+		amountExpr, _ := cmd.Flags().GetString("amount")
 		day, _ := cmd.Flags().GetInt("day")
 		category, _ := cmd.Flags().GetString("category")
+		timeOfDay, _ := cmd.Flags().GetString("time")
 
-		if title == "" {
-			log.Fatal("Error: title flag is required.")
+		offset, relative, err := dayOffset(cmd)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
+		if relative {
+			if cmd.Flags().Changed("day") {
+				log.Fatal("Error: --day cannot be combined with --tomorrow, --yesterday or --in.")
+			}
+			// Expenses fall on days 1-28. Clamping a later date would
+			// silently store a different day, possibly today's, so it is
+			// refused instead.
+			target := currentTime().AddDate(0, 0, offset)
+			if target.Day() > 28 {
+				log.Fatalf("Error: That date is %s, but expenses must fall on days 1-28. Use --day to pick one.", target.Format("2006-01-02"))
+			}
+			day = target.Day()
+		}
+
+		if len(args) > 0 {
+			if relative {
+				log.Fatal("Error: --tomorrow, --yesterday and --in cannot be combined with the quick form; use @day instead.")
+			}
+			for _, name := range []string{"title", "amount", "day"} {
+				if cmd.Flags().Changed(name) {
+					log.Fatalf("Error: --%s cannot be combined with the quick \"Title amount @day #category\" form.", name)
+				}
+			}
+			title, amountExpr, day, category, err = parseQuickAdd(strings.Join(args, " "), category)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		} else {
+			// An amount must be given explicitly, even as 0; the flag's
+			// empty default never stands in for one.
+			amountGiven := cmd.Flags().Changed("amount")
+			fromClipboard, _ := cmd.Flags().GetBool("amount-from-clipboard")
+			if fromClipboard && !amountGiven {
+				amountExpr = clipboardAmount()
+				amountGiven = amountExpr != ""
+			}
 
-		if day < 1 || day > 28 {
-			log.Fatalf("Error: Invalid day '%d'. Please provide a day between 1 and 28.", day)
+			var missing []string
+			for _, name := range []string{"title", "amount", "day"} {
+				given := cmd.Flags().Changed(name)
+				switch name {
+				case "amount":
+					given = amountGiven
+				case "day":
+					given = given || relative
+				}
+				if !given {
+					missing = append(missing, fmt.Sprintf("%q", name))
+				}
+			}
+			if len(missing) > 0 {
+				log.Fatalf("Error: required flag(s) %s not set", strings.Join(missing, ", "))
+			}
 		}
 
-		insertSQL := `INSERT INTO expenses(title, amount, day, category) VALUES (?, ?, ?, ?)`
-		statement, err := db.Prepare(insertSQL)
+		amount, err := evalAmount(amountExpr)
 		if err != nil {
-			log.Fatalf("Error preparing insert statement: %v", err)
+			log.Fatalf("Error: %v", err)
+		}
+		if roundTo, _ := cmd.Flags().GetFloat64("round-to"); roundTo != 0 {
+			if roundTo < 0 {
+				log.Fatalf("Error: Invalid round-to '%g'. Please provide a positive number.", roundTo)
+			}
+			amount = roundToMultiple(amount, roundTo)
+		}
+
+		category = resolveCategory(category)
+
+		exp := Expense{
+			Title:    title,
+			Amount:   amount,
+			Day:      day,
+			Category: category,
+			Time:     timeOfDay,
+		}
+		if err := validateExpense(&exp); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		window := cfg.DupCheckWindow
+		if cmd.Flags().Changed("dup-check-window") {
+			window, _ = cmd.Flags().GetInt("dup-check-window")
+		}
+		if window >= 0 {
+			warnDuplicates(exp, window, cfg.DupCheckCategory)
 		}
-		defer statement.Close()
 
-		_, err = statement.Exec(title, amount, day, category)
+		force, _ := cmd.Flags().GetBool("force")
+		checkBudget(exp.Category, exp.Amount, force)
+
+		err = retryBusy(func() error {
+			exp.ID, err = insertExpense(db, exp)
+			return err
+		})
 		if err != nil {
 			log.Fatalf("Error executing insert statement: %v", err)
 		}
+		runPostAddHook(exp)
+		notifyWebhook(webhookPayload{Event: "add", Expense: &exp})
 	},
 }
 
+// dayOffset reads --tomorrow, --yesterday and --in as a number of days
+// from today. relative is false when none of them is given.
+func dayOffset(cmd *cobra.Command) (offset int, relative bool, err error) {
+	if tomorrow, _ := cmd.Flags().GetBool("tomorrow"); tomorrow {
+		return 1, true, nil
+	}
+	if yesterday, _ := cmd.Flags().GetBool("yesterday"); yesterday {
+		return -1, true, nil
+	}
+	if !cmd.Flags().Changed("in") {
+		return 0, false, nil
+	}
+	in, _ := cmd.Flags().GetString("in")
+	offset, err = strconv.Atoi(strings.TrimSuffix(in, "d"))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid --in '%s', expected a number of days such as 3d", in)
+	}
+	return offset, true, nil
+}
+
+// validateExpense checks the fields shared by every way of adding an
+// expense and normalizes the time of day to HH:MM.
+func validateExpense(exp *Expense) error {
+	if exp.Title == "" {
+		return errors.New("title is required")
+	}
+
+	if categoryRequired() && strings.TrimSpace(exp.Category) == "" {
+		return errors.New("category is required")
+	}
+
+	if exp.Day < 1 || exp.Day > 28 {
+		return fmt.Errorf("invalid day '%d', please provide a day between 1 and 28", exp.Day)
+	}
+
+	if exp.Time != "" {
+		parsed, err := time.Parse("15:04", exp.Time)
+		if err != nil {
+			return fmt.Errorf("invalid time '%s', please use the 24-hour HH:MM format", exp.Time)
+		}
+		exp.Time = parsed.Format("15:04")
+	}
+	return nil
+}
+
+// parseQuickAdd splits the quick add form into its fields: the first word
+// that is a number is the amount, @N the day, #X the category and the rest
+// the title. category is kept when the text names none.
+func parseQuickAdd(text, category string) (title, amount string, day int, cat string, err error) {
+	cat = category
+	var words []string
+	for _, word := range strings.Fields(text) {
+		switch {
+		case strings.HasPrefix(word, "@") && day == 0:
+			day, err = strconv.Atoi(word[1:])
+			if err != nil {
+				return "", "", 0, "", fmt.Errorf("invalid day '%s' in quick add", word)
+			}
+		case strings.HasPrefix(word, "#") && len(word) > 1:
+			cat = word[1:]
+		case amount == "" && isAmountWord(word):
+			amount = word
+		default:
+			words = append(words, word)
+		}
+	}
+
+	title = strings.Join(words, " ")
+	var missing []string
+	if title == "" {
+		missing = append(missing, "title")
+	}
+	if amount == "" {
+		missing = append(missing, "amount")
+	}
+	if day == 0 {
+		missing = append(missing, "@day")
+	}
+	if len(missing) > 0 {
+		return "", "", 0, "", fmt.Errorf("quick add is missing %s, e.g. \"Coffee 3.50 @15 #Food\"", strings.Join(missing, ", "))
+	}
+	return title, amount, day, cat, nil
+}
+
+// isAmountWord reports whether a word of a quick add is an amount rather
+// than part of the title.
+func isAmountWord(word string) bool {
+	if decimalComma {
+		converted, err := fromDecimalComma(word)
+		if err != nil {
+			return false
+		}
+		word = converted
+	}
+	_, err := strconv.ParseFloat(word, 64)
+	return err == nil
+}
+
+// warnDuplicates warns about existing expenses with the same title and
+// amount due within window days of exp, and in the same category when
+// matchCategory is set.
+func warnDuplicates(exp Expense, window int, matchCategory bool) {
+	query := selectExpensesSQL + " WHERE title = ? COLLATE NOCASE AND amount = ? AND ABS(day - ?) <= ?"
+	args := []any{exp.Title, exp.Amount, exp.Day, window}
+	if matchCategory {
+		query += " AND IFNULL(category, '') = ?"
+		args = append(args, exp.Category)
+	}
+	for _, dup := range queryExpenses(query+" ORDER BY id ASC", args...) {
+		log.Printf("Warning: this looks like a duplicate of expense %d (%s, %s on day %d).", dup.ID, dup.Title, formatAmount(dup.Amount), dup.Day)
+	}
+}
+
+// clipboardNumber matches the first number in the clipboard text, allowing
+// thousands separators, e.g. "Total: $1,234.50".
+var clipboardNumber = regexp.MustCompile(`-?\d+(,\d+)*(\.\d+)?`)
+
+// clipboardDecimalCommaNumber is clipboardNumber for --decimal-comma,
+// e.g. "Summe: 1.234,50 €".
+var clipboardDecimalCommaNumber = regexp.MustCompile(`-?\d[\d.]*(,\d+)?`)
+
+// clipboardAmount returns the first number found on the system clipboard,
+// or "" when the clipboard cannot be read or holds no number.
+func clipboardAmount() string {
+	text, err := clipboard.ReadAll()
+	if err != nil {
+		log.Printf("Warning: could not read the clipboard: %v", err)
+		return ""
+	}
+	if decimalComma {
+		return clipboardDecimalCommaNumber.FindString(text)
+	}
+	match := clipboardNumber.FindString(text)
+	number, err := fromThousandsComma(match)
+	if err != nil {
+		log.Fatalf("Error: Ambiguous amount '%s' on the clipboard: %v", match, err)
+	}
+	return number
+}
+
+// roundToMultiple rounds amount to the nearest multiple of step, trimming
+// the float noise that multiplying by fractional steps like 0.05 leaves.
+func roundToMultiple(amount, step float64) float64 {
+	rounded := math.Round(amount/step) * step
+	return math.Round(rounded*1e9) / 1e9
+}
+
+// decodeExpenseJSON decodes one expense object, requiring an amount key so
+// that a forgotten amount is not silently stored as 0.
+func decodeExpenseJSON(data []byte) (Expense, error) {
+	var exp Expense
+	if err := json.Unmarshal(data, &exp); err != nil {
+		return exp, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return exp, err
+	}
+	if _, ok := fields["amount"]; !ok {
+		return exp, errors.New("amount is required")
+	}
+	return exp, nil
+}
+
+// addFromJSON inserts an array of expense objects read from r. Every element
+// is validated first; if any fail, the errors are reported by array index
+// and nothing is inserted.
+func addFromJSON(r io.Reader) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&elements); err != nil {
+		log.Fatalf("Error decoding JSON input: %v", err)
+	}
+
+	aliases := newCategoryAliases(cfg.CategoryAliases, nil)
+	failed := 0
+	expenses := make([]Expense, len(elements))
+	for i, element := range elements {
+		var err error
+		expenses[i], err = decodeExpenseJSON(element)
+		if err == nil {
+			if to, ok := aliases.resolve(expenses[i].Category); ok {
+				expenses[i].Category = to
+			}
+			err = validateExpense(&expenses[i])
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[%d]: %v\n", i, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Fatalf("Error: %d expense(s) failed validation; nothing was added.", failed)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatalf("Error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for i := range expenses {
+		expenses[i].ID, err = insertExpense(tx, expenses[i])
+		if err != nil {
+			log.Fatalf("Error inserting expense [%d]: %v", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("Error committing expenses: %v", err)
+	}
+
+	fmt.Printf("Added %d expenses.\n", len(expenses))
+	for _, exp := range expenses {
+		runPostAddHook(exp)
+		notifyWebhook(webhookPayload{Event: "add", Expense: &exp})
+	}
+}
+
 func init() {
 	addCmd.Flags().StringP("title", "t", "", "Title of the expense (required)")
-	addCmd.Flags().Float64P("amount", "a", 0.0, "Amount of the expense (required)")
+	addCmd.Flags().StringP("amount", "a", "", "Amount of the expense, or an expression like 3*4.50 (required)")
 	addCmd.Flags().IntP("day", "d", 0, "Day of the month (1-28) for the expense (required)")
+	addCmd.Flags().Bool("tomorrow", false, "Set the day to tomorrow's day of the month")
+	addCmd.Flags().Bool("yesterday", false, "Set the day to yesterday's day of the month")
+	addCmd.Flags().String("in", "", "Set the day to this many days from today, e.g. 3d")
 	addCmd.Flags().StringP("category", "c", "", "Category of the expense (optional)")
+	addCmd.Flags().String("time", "", "Time of day (HH:MM, 24-hour) for the expense (optional)")
+	addCmd.Flags().BoolVar(&decimalComma, "decimal-comma", false, "Read amounts with a comma as the decimal separator, e.g. 1.234,50")
+	addCmd.Flags().Bool("amount-from-clipboard", false, "Read the amount from the first number on the clipboard when --amount is not given")
+	addCmd.Flags().Float64("round-to", 0, "Round the amount to the nearest multiple of this step, e.g. 0.05 (optional)")
+	addCmd.Flags().Int("dup-check-window", 0, "Warn about an expense with the same title and amount within this many days (-1 disables; default from config)")
+	addCmd.Flags().Bool("force", false, "Add the expense even if it exceeds the category budget in strict mode")
+	addCmd.Flags().Bool("json-stdin", false, "Read a JSON array of expenses from stdin instead of flags")
 
-	addCmd.MarkFlagRequired("title")
-	addCmd.MarkFlagRequired("amount")
-	addCmd.MarkFlagRequired("day")
+	// title, amount and day are required unless --json-stdin is used; that
+	// is checked in Run because cobra cannot express conditional requirements.
+	addCmd.MarkFlagsMutuallyExclusive("json-stdin", "title")
+	addCmd.MarkFlagsMutuallyExclusive("json-stdin", "amount")
+	addCmd.MarkFlagsMutuallyExclusive("json-stdin", "day")
+	addCmd.MarkFlagsMutuallyExclusive("tomorrow", "yesterday", "in")
 }
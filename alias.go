@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// categoryAliases rewrites category names, such as a bank's "AMZN", into
+// the user's own categories. It is keyed by the lowercased alias, so
+// lookups ignore case.
+type categoryAliases map[string]aliasMapping
+
+// aliasMapping is one alias, as configured, and its category.
+type aliasMapping struct {
+	from, to string
+}
+
+// newCategoryAliases builds the lookup table from the configured aliases,
+// with extra taking precedence.
+func newCategoryAliases(configured, extra map[string]string) categoryAliases {
+	aliases := make(categoryAliases)
+	for _, m := range []map[string]string{configured, extra} {
+		for from, to := range m {
+			aliases[strings.ToLower(from)] = aliasMapping{from, to}
+		}
+	}
+	return aliases
+}
+
+// resolve returns the category that category is an alias of, and whether
+// it is one.
+func (a categoryAliases) resolve(category string) (string, bool) {
+	m, ok := a[strings.ToLower(strings.TrimSpace(category))]
+	return m.to, ok
+}
+
+// resolveCategory applies the configured aliases to a category about to
+// be written, returning it unchanged when it is not an alias.
+func resolveCategory(category string) string {
+	if to, ok := newCategoryAliases(cfg.CategoryAliases, nil).resolve(category); ok {
+		return to
+	}
+	return category
+}
+
+// parseCategoryAliases parses "From=To,From=To" as used by
+// --category-aliases and 'config set category_aliases'.
+func parseCategoryAliases(value string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return aliases, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		from, to, _ := strings.Cut(pair, "=")
+		aliases[strings.TrimSpace(from)] = strings.TrimSpace(to)
+	}
+	if err := validateCategoryAliases(aliases); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+func validateCategoryAliases(aliases map[string]string) error {
+	for from, to := range aliases {
+		if from == "" || to == "" {
+			return fmt.Errorf("invalid category alias '%s=%s': expected From=To with both names non-empty", from, to)
+		}
+	}
+	return nil
+}
+
+// formatCategoryAliases writes aliases back in the form parseCategoryAliases
+// reads, sorted by alias.
+func formatCategoryAliases(aliases map[string]string) string {
+	pairs := make([]string, 0, len(aliases))
+	for from, to := range aliases {
+		pairs = append(pairs, from+"="+to)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// aliasRewriter rewrites the categories of expenses one at a time, as they
+// are read, and counts the rewrites per alias.
+type aliasRewriter struct {
+	aliases categoryAliases
+	mapped  map[aliasMapping]int
+}
+
+func newAliasRewriter(aliases categoryAliases) *aliasRewriter {
+	return &aliasRewriter{aliases: aliases, mapped: make(map[aliasMapping]int)}
+}
+
+// validate rewrites the category of exp if it is an alias and then
+// validates exp, so that it is judged by the category it will be stored
+// under. Only expenses that pass are counted.
+func (r *aliasRewriter) validate(exp *Expense) error {
+	m, ok := r.aliases[strings.ToLower(strings.TrimSpace(exp.Category))]
+	if ok {
+		exp.Category = m.to
+	}
+	if err := validateExpense(exp); err != nil {
+		return err
+	}
+	if ok {
+		r.mapped[m]++
+	}
+	return nil
+}
+
+// printAliasMappings reports the rewrites counted by an aliasRewriter, most
+// frequent first.
+func printAliasMappings(mapped map[aliasMapping]int) {
+	mappings := make([]aliasMapping, 0, len(mapped))
+	for m := range mapped {
+		mappings = append(mappings, m)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		if mapped[mappings[i]] != mapped[mappings[j]] {
+			return mapped[mappings[i]] > mapped[mappings[j]]
+		}
+		return mappings[i].from < mappings[j].from
+	})
+	for _, m := range mappings {
+		fmt.Printf("Mapped %d %s → %s.\n", mapped[m], m.from, m.to)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Manage monthly per-category budgets",
+}
+
+var budgetSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the monthly budget for a category",
+	Run: func(cmd *cobra.Command, _ []string) {
+		requireWritable(cmd)
+
+		category, _ := cmd.Flags().GetString("category")
+		limit, _ := cmd.Flags().GetFloat64("limit")
+		percent, _ := cmd.Flags().GetFloat64("percent")
+
+		if category == "" {
+			log.Fatal("Error: category flag is required.")
+		}
+
+		if cmd.Flags().Changed("percent") {
+			if percent <= 0 || percent > 100 {
+				log.Fatalf("Error: Invalid percent '%g'. Please provide a value between 0 and 100.", percent)
+			}
+			if err := setPercentBudget(db, category, percent); err != nil {
+				log.Fatalf("Error setting budget: %v", err)
+			}
+			fmt.Printf("Budget for %s set to %g%% of income.\n", category, percent)
+			if cfg.Income <= 0 {
+				log.Print("Warning: no income is set, so this budget has no effect until you run 'monke config set income <amount>'.")
+			}
+			return
+		}
+
+		if limit <= 0 {
+			log.Fatalf("Error: Invalid limit '%.2f'. Please provide a positive amount.", limit)
+		}
+		if err := setBudget(db, category, limit); err != nil {
+			log.Fatalf("Error setting budget: %v", err)
+		}
+		fmt.Printf("Budget for %s set to %s.\n", category, formatAmount(limit))
+	},
+}
+
+var budgetLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List category budgets",
+	Run: func(_ *cobra.Command, _ []string) {
+		budgets := loadBudgetRows()
+		if len(budgets) == 0 {
+			fmt.Println("No budgets set.")
+			return
+		}
+
+		table := newTable([]string{"Category", "Limit"}, []int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT})
+		for _, b := range budgets {
+			limit := formatAmount(b.amount)
+			if b.percent.Valid {
+				if cfg.Income > 0 {
+					limit = fmt.Sprintf("%s (%g%% of income)", formatAmount(b.limit()), b.percent.Float64)
+				} else {
+					limit = fmt.Sprintf("%g%% of income (no income set)", b.percent.Float64)
+				}
+			}
+			table.Append([]string{b.category, limit})
+		}
+		table.Render()
+	},
+}
+
+var budgetImportCmd = &cobra.Command{
+	Use:   "import <file.csv|file.toml>",
+	Short: "Set many budgets from a CSV or TOML file",
+	Long: `Set many budgets at once. A CSV file has category,limit rows with an
+optional header; a TOML file maps each category to its limit, e.g.
+  Food = 400
+  "Eating Out" = 150
+
+CSV limits are read like 'import' amounts: use --decimal-comma for limits
+such as "1.000,00", and limits with ambiguous separators are rejected.
+
+Every budget is validated first; if any fail, nothing is set.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireWritable(cmd)
+
+		budgets, err := readBudgetFile(args[0])
+		if err != nil {
+			log.Fatalf("Error reading budget file: %v", err)
+		}
+
+		failed := 0
+		for _, b := range budgets {
+			if b.category == "" {
+				fmt.Fprintf(os.Stderr, "%s: category is empty\n", b.source)
+				failed++
+			} else if b.limit <= 0 {
+				fmt.Fprintf(os.Stderr, "%s: invalid limit '%g' for %s, must be positive\n", b.source, b.limit, b.category)
+				failed++
+			}
+		}
+		if failed > 0 {
+			log.Fatalf("Error: %d budget(s) are invalid; nothing was set.", failed)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Fatalf("Error starting transaction: %v", err)
+		}
+		defer tx.Rollback()
+
+		for _, b := range budgets {
+			if err := setBudget(tx, b.category, b.limit); err != nil {
+				log.Fatalf("Error setting budget for %s: %v", b.category, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("Error committing budgets: %v", err)
+		}
+
+		fmt.Printf("Set %d budget(s).\n", len(budgets))
+	},
+}
+
+// budgetEntry is a budget read from an import file, with where it came
+// from for error messages.
+type budgetEntry struct {
+	category string
+	limit    float64
+	source   string
+}
+
+// readBudgetFile reads budgets from a TOML file, by extension, or else a
+// category,limit CSV file.
+func readBudgetFile(path string) ([]budgetEntry, error) {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		var limits map[string]float64
+		if _, err := toml.DecodeFile(path, &limits); err != nil {
+			return nil, err
+		}
+		var budgets []budgetEntry
+		for category, limit := range limits {
+			budgets = append(budgets, budgetEntry{strings.TrimSpace(category), limit, category})
+		}
+		sort.Slice(budgets, func(i, j int) bool { return budgets[i].category < budgets[j].category })
+		return budgets, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var budgets []budgetEntry
+	for i, record := range records {
+		source := fmt.Sprintf("line %d", i+1)
+		if len(record) != 2 {
+			return nil, fmt.Errorf("%s: expected category,limit but got %d fields", source, len(record))
+		}
+		limit, err := parseAmount(record[1])
+		if err != nil {
+			// A first row that is not a number is a header.
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("%s: %v", source, err)
+		}
+		budgets = append(budgets, budgetEntry{strings.TrimSpace(record[0]), limit, source})
+	}
+	return budgets, nil
+}
+
+// setBudget inserts or replaces the budget for a category with an absolute
+// limit.
+func setBudget(ex execer, category string, limit float64) error {
+	upsertSQL := `INSERT INTO budgets(category, amount, percent) VALUES (?, ?, NULL)
+		ON CONFLICT(category) DO UPDATE SET amount = excluded.amount, percent = NULL`
+	explain(upsertSQL, category, limit)
+	_, err := ex.Exec(upsertSQL, category, limit)
+	return err
+}
+
+// setPercentBudget inserts or replaces the budget for a category with a
+// limit that is a percentage of the configured income.
+func setPercentBudget(ex execer, category string, percent float64) error {
+	upsertSQL := `INSERT INTO budgets(category, amount, percent) VALUES (?, 0, ?)
+		ON CONFLICT(category) DO UPDATE SET amount = 0, percent = excluded.percent`
+	explain(upsertSQL, category, percent)
+	_, err := ex.Exec(upsertSQL, category, percent)
+	return err
+}
+
+// budgetRow is a stored budget: an absolute amount, or a percent of income
+// when percent is set.
+type budgetRow struct {
+	category string
+	amount   float64
+	percent  sql.NullFloat64
+}
+
+// limit returns the budget's effective monthly limit, or 0 for a percent
+// budget while no income is configured.
+func (b budgetRow) limit() float64 {
+	if b.percent.Valid {
+		return cfg.Income * b.percent.Float64 / 100
+	}
+	return b.amount
+}
+
+// loadBudgetRows returns every stored budget, ordered by category.
+func loadBudgetRows() []budgetRow {
+	query := "SELECT category, amount, percent FROM budgets ORDER BY category ASC"
+	explain(query)
+	rows, err := db.Query(query)
+	if err != nil {
+		log.Fatalf("Error querying budgets: %v", err)
+	}
+	defer rows.Close()
+
+	var budgets []budgetRow
+	for rows.Next() {
+		var b budgetRow
+		if err := rows.Scan(&b.category, &b.amount, &b.percent); err != nil {
+			log.Fatalf("Error scanning budget: %v", err)
+		}
+		budgets = append(budgets, b)
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatalf("Error iterating budgets: %v", err)
+	}
+	return budgets
+}
+
+// loadBudgets returns the effective monthly budget limit for each category
+// that has one. Percent budgets are skipped while no income is set.
+func loadBudgets() map[string]float64 {
+	budgets := make(map[string]float64)
+	for _, b := range loadBudgetRows() {
+		if limit := b.limit(); limit > 0 {
+			budgets[b.category] = limit
+		}
+	}
+	return budgets
+}
+
+// checkBudget warns when adding amount to category would exceed its monthly
+// budget. In strict mode the add is refused unless force is set.
+func checkBudget(category string, amount float64, force bool) {
+	if category == "" {
+		return
+	}
+
+	b := budgetRow{category: category}
+	budgetSQL := "SELECT amount, percent FROM budgets WHERE category = ?"
+	explain(budgetSQL, category)
+	err := db.QueryRow(budgetSQL, category).Scan(&b.amount, &b.percent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Error querying budget: %v", err)
+	}
+	budget := b.limit()
+	if budget <= 0 {
+		return
+	}
+
+	var spent float64
+	spentSQL := "SELECT IFNULL(SUM(amount), 0) FROM expenses WHERE category = ?"
+	explain(spentSQL, category)
+	if err := db.QueryRow(spentSQL, category).Scan(&spent); err != nil {
+		log.Fatalf("Error querying category total: %v", err)
+	}
+
+	overshoot := spent + amount - budget
+	if overshoot <= 0 {
+		return
+	}
+	message := fmt.Sprintf("this expense takes %s over its %s budget by %s", category, formatAmount(budget), formatAmount(overshoot))
+	if cfg.StrictBudgets && !force {
+		log.Fatalf("Error: %s. Use --force to add it anyway.", message)
+	}
+	log.Printf("Warning: %s.", message)
+}
+
+func init() {
+	budgetSetCmd.Flags().StringP("category", "c", "", "Category to budget (required)")
+	budgetSetCmd.Flags().Float64P("limit", "l", 0, "Monthly spending limit for the category")
+	budgetSetCmd.Flags().Float64P("percent", "p", 0, "Monthly limit as a percentage of the configured income")
+	budgetSetCmd.MarkFlagRequired("category")
+	budgetSetCmd.MarkFlagsOneRequired("limit", "percent")
+	budgetSetCmd.MarkFlagsMutuallyExclusive("limit", "percent")
+
+	budgetImportCmd.Flags().BoolVar(&decimalComma, "decimal-comma", false, "Read CSV limits with a comma as the decimal separator, e.g. 1.000,00")
+
+	budgetCmd.AddCommand(budgetSetCmd)
+	budgetCmd.AddCommand(budgetLsCmd)
+	budgetCmd.AddCommand(budgetImportCmd)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var categoriesCmd = &cobra.Command{
+	Use:   "categories",
+	Short: "Category maintenance commands",
+}
+
+var categoriesPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Report, or remove with --force, budgets for categories without expenses",
+	Run: func(cmd *cobra.Command, _ []string) {
+		force, _ := cmd.Flags().GetBool("force")
+		if force {
+			requireWritable(cmd)
+		}
+
+		query := `SELECT category FROM budgets
+			WHERE category NOT IN (SELECT DISTINCT category FROM expenses WHERE category IS NOT NULL)
+			ORDER BY category ASC`
+		explain(query)
+		rows, err := db.Query(query)
+		if err != nil {
+			log.Fatalf("Error querying budgets: %v", err)
+		}
+		var orphaned []string
+		for rows.Next() {
+			var category string
+			if err := rows.Scan(&category); err != nil {
+				log.Fatalf("Error scanning budget: %v", err)
+			}
+			orphaned = append(orphaned, category)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			log.Fatalf("Error iterating budgets: %v", err)
+		}
+
+		if len(orphaned) == 0 {
+			fmt.Println("No orphaned categories found.")
+			return
+		}
+
+		fmt.Println("Budgeted categories with no expenses:")
+		for _, category := range orphaned {
+			fmt.Printf("  - %s\n", category)
+		}
+
+		if !force {
+			fmt.Println("\nRun with --force to remove these budgets.")
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Fatalf("Error starting transaction: %v", err)
+		}
+		defer tx.Rollback()
+
+		deleteSQL := "DELETE FROM budgets WHERE category = ?"
+		for _, category := range orphaned {
+			explain(deleteSQL, category)
+			if _, err := tx.Exec(deleteSQL, category); err != nil {
+				log.Fatalf("Error removing budget for %s: %v", category, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("Error committing prune: %v", err)
+		}
+		fmt.Printf("\nRemoved %d budget(s).\n", len(orphaned))
+	},
+}
+
+var categoriesColorsCmd = &cobra.Command{
+	Use:   "colors",
+	Short: "Preview the color ls assigns to each category",
+	Run: func(_ *cobra.Command, _ []string) {
+		expenses := queryExpenses(selectExpensesSQL)
+		if len(expenses) == 0 {
+			printNoExpenses()
+			return
+		}
+
+		categoryTotalsMap := make(map[string]float64)
+		for _, exp := range expenses {
+			categoryTotalsMap[categoryLabel(exp.Category)] += exp.Amount
+		}
+		categoryColorMap := assignCategoryColors(categoryTotalsMap)
+
+		var categories []string
+		for cat := range categoryTotalsMap {
+			categories = append(categories, cat)
+		}
+		sort.Strings(categories)
+
+		table := newTable([]string{"Category", "Swatch", "Code"}, []int{
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_RIGHT,
+		})
+		for _, cat := range categories {
+			color := categoryColorMap[cat]
+			table.Append([]string{
+				colorize(color, cat),
+				colorize(color, strings.Repeat(lineCharacter, 6)),
+				colorCode(color),
+			})
+		}
+		table.Render()
+	},
+}
+
+// colorCode returns the 256-color code of a "\033[38;5;Nm" escape sequence,
+// the form accepted by 'ls --color'.
+func colorCode(color string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(color, "\033[38;5;"), "m")
+}
+
+func init() {
+	categoriesPruneCmd.Flags().Bool("force", false, "Remove the orphaned budgets instead of only reporting them")
+
+	categoriesCmd.AddCommand(categoriesPruneCmd)
+	categoriesCmd.AddCommand(categoriesColorsCmd)
+}
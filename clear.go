@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -13,27 +14,59 @@ import (
 var clearCmd = &cobra.Command{
 	Use:   "clear",
 	Short: "Delete all expenses from the database",
-	Run: func(_ *cobra.Command, _ []string) {
+	Run: func(cmd *cobra.Command, _ []string) {
+		requireWritable(cmd)
+
+		keepSequence, _ := cmd.Flags().GetBool("keep-sequence")
+
+		var count int
+		countSQL := `SELECT COUNT(*) FROM expenses;`
+		explain(countSQL)
+		if err := db.QueryRow(countSQL).Scan(&count); err != nil {
+			log.Fatalf("Error counting expenses: %v", err)
+		}
+
 		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("Are you sure you want to delete ALL expenses? This cannot be undone. [y/N]: ")
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(strings.ToLower(input))
-		if input == "y" || input == "yes" {
+		confirmed := false
+		if count > cfg.ClearConfirmThreshold {
+			fmt.Printf("This will delete %d expenses and cannot be undone. Type the number of expenses to confirm: ", count)
+			input, _ := reader.ReadString('\n')
+			confirmed = strings.TrimSpace(input) == strconv.Itoa(count)
+		} else {
+			fmt.Print("Are you sure you want to delete ALL expenses? This cannot be undone. [y/N]: ")
+			input, _ := reader.ReadString('\n')
+			input = strings.TrimSpace(strings.ToLower(input))
+			confirmed = input == "y" || input == "yes"
+		}
+
+		if confirmed {
 			deleteSQL := `DELETE FROM expenses;`
 			resetSeqSQL := `DELETE FROM sqlite_sequence WHERE name='expenses';`
-			_, err := db.Exec(deleteSQL)
+			err := retryBusy(func() error {
+				explain(deleteSQL)
+				_, err := db.Exec(deleteSQL)
+				return err
+			})
 			if err != nil {
 				log.Fatalf("Error deleting expenses: %v", err)
 			}
 
-			_, err = db.Exec(resetSeqSQL)
-			if err != nil {
-				log.Printf("Warning: Could not reset sequence counter: %v", err)
+			if !keepSequence {
+				explain(resetSeqSQL)
+				_, err = db.Exec(resetSeqSQL)
+				if err != nil {
+					log.Printf("Warning: Could not reset sequence counter: %v", err)
+				}
 			}
 
 			fmt.Println("All expenses have been deleted.")
+			notifyWebhook(webhookPayload{Event: "clear", Deleted: count})
 		} else {
 			fmt.Println("Operation cancelled.")
 		}
 	},
 }
+
+func init() {
+	clearCmd.Flags().Bool("keep-sequence", false, "Keep the ID counter so new expenses continue from the previous max ID")
+}
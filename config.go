@@ -0,0 +1,628 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+)
+
+type Config struct {
+	Currency string `toml:"currency,omitempty"`
+	Width    int    `toml:"width"`
+	Timezone string `toml:"timezone,omitempty"`
+	Locale   string `toml:"locale,omitempty"`
+
+	// SecondaryRate converts amounts into SecondaryCurrency for display in
+	// ls; 0 disables it.
+	SecondaryCurrency string  `toml:"secondary_currency,omitempty"`
+	SecondaryRate     float64 `toml:"secondary_rate,omitempty"`
+
+	// Income is the monthly income used for percent-of-income views.
+	Income float64 `toml:"income,omitempty"`
+
+	// Payday is the day of the month income arrives, used by
+	// 'ls --pay-period'; 0 means unset.
+	Payday int `toml:"payday,omitempty"`
+
+	// ClearConfirmThreshold is the number of expenses above which clear
+	// asks for the exact count instead of y/N.
+	ClearConfirmThreshold int `toml:"clear_confirm_threshold"`
+
+	// StrictBudgets makes add refuse, without --force, an expense that
+	// would take its category over budget.
+	StrictBudgets bool `toml:"strict_budgets"`
+
+	// CategoryRequired rejects expenses without a category wherever they
+	// are written: add, edit, import, merge and recategorize.
+	CategoryRequired bool `toml:"category_required"`
+
+	// CategoryAliases rewrites categories, matched without regard to
+	// case, whenever an expense's category is written, e.g.
+	// AMZN = "Shopping".
+	CategoryAliases map[string]string `toml:"category_aliases,omitempty"`
+
+	// DupCheckWindow is how many days apart an expense with the same title
+	// and amount may be for add to warn about a duplicate; -1 disables
+	// the check. DupCheckCategory also requires the category to match.
+	DupCheckWindow   int  `toml:"dup_check_window"`
+	DupCheckCategory bool `toml:"dup_check_category"`
+
+	// CompactThreshold is the smallest amount 'ls --compact' shortens.
+	CompactThreshold float64 `toml:"compact_threshold"`
+
+	// CategorySeparator splits hierarchical categories such as
+	// "Food:Dining" so totals roll up into the parent; empty disables it.
+	CategorySeparator string `toml:"category_separator,omitempty"`
+
+	// StatusGlyph and BarChar replace the status dot and category bar
+	// character for fonts that render them poorly; empty keeps the default.
+	StatusGlyph string `toml:"status_glyph,omitempty"`
+	BarChar     string `toml:"bar_char,omitempty"`
+
+	// PostAddHook is a shell command run after each expense is added; see
+	// runPostAddHook. It runs with the user's privileges, so only set it to
+	// commands you trust, and keep the config file writable only by you.
+	PostAddHook string `toml:"post_add_hook,omitempty"`
+
+	// WebhookURL receives a JSON POST for each of WebhookEvents (add by
+	// default); see notifyWebhook.
+	WebhookURL    string   `toml:"webhook_url,omitempty"`
+	WebhookEvents []string `toml:"webhook_events,omitempty"`
+
+	Database DatabaseConfig `toml:"database"`
+}
+
+// DatabaseConfig tunes the SQLite connection pool.
+//
+// SQLite allows a single writer at a time, so with more than one open
+// connection concurrent writes fail with "database is locked" instead of
+// queueing. A single connection serializes all access in database/sql
+// and is the safest default.
+type DatabaseConfig struct {
+	MaxOpenConns    int           `toml:"max_open_conns"`
+	MaxIdleConns    int           `toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `toml:"conn_max_lifetime"`
+}
+
+var (
+	cfg = defaultConfig()
+
+	// timezoneFlag overrides the configured timezone for a single run.
+	timezoneFlag string
+
+	// location is the timezone used to decide what "today" is.
+	location = time.Local
+
+	// nowFlag overrides today's date, as YYYY-MM-DD, for --now and
+	// MONKE_NOW; nowOverride holds the parsed date.
+	nowFlag     string
+	nowOverride time.Time
+
+	// statusGlyphFlag and barCharFlag override the configured glyphs for a
+	// single run; asciiOutput falls back to ASCII for those not given.
+	statusGlyphFlag string
+	barCharFlag     string
+	asciiOutput     bool
+
+	// categoryRequiredFlag turns on category_required for a single run.
+	categoryRequiredFlag bool
+)
+
+func defaultConfig() Config {
+	return Config{
+		Width:                 80,
+		ClearConfirmThreshold: 50,
+		CompactThreshold:      1000,
+		DupCheckCategory:      true,
+		Database: DatabaseConfig{
+			MaxOpenConns: 1,
+			MaxIdleConns: 1,
+		},
+	}
+}
+
+// configKey describes a setting that can be read and written with
+// 'monke config get/set'.
+type configKey struct {
+	get func(c *Config) string
+	set func(c *Config, value string) error
+}
+
+var configKeys = map[string]configKey{
+	"currency": {
+		get: func(c *Config) string { return c.Currency },
+		set: func(c *Config, value string) error {
+			if err := validateCurrency(value); err != nil {
+				return err
+			}
+			c.Currency = value
+			return nil
+		},
+	},
+	"width": {
+		get: func(c *Config) string { return strconv.Itoa(c.Width) },
+		set: func(c *Config, value string) error {
+			width, err := strconv.Atoi(value)
+			if err != nil || width <= 0 {
+				return fmt.Errorf("invalid width '%s': must be a positive integer", value)
+			}
+			c.Width = width
+			return nil
+		},
+	},
+	"clear_confirm_threshold": {
+		get: func(c *Config) string { return strconv.Itoa(c.ClearConfirmThreshold) },
+		set: func(c *Config, value string) error {
+			threshold, err := strconv.Atoi(value)
+			if err != nil || threshold < 0 {
+				return fmt.Errorf("invalid clear_confirm_threshold '%s': must be a non-negative integer", value)
+			}
+			c.ClearConfirmThreshold = threshold
+			return nil
+		},
+	},
+	"secondary_currency": {
+		get: func(c *Config) string { return c.SecondaryCurrency },
+		set: func(c *Config, value string) error {
+			if err := validateCurrency(value); err != nil {
+				return err
+			}
+			c.SecondaryCurrency = value
+			return nil
+		},
+	},
+	"secondary_rate": {
+		get: func(c *Config) string { return strconv.FormatFloat(c.SecondaryRate, 'f', -1, 64) },
+		set: func(c *Config, value string) error {
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate < 0 {
+				return fmt.Errorf("invalid secondary_rate '%s': must be a non-negative number", value)
+			}
+			c.SecondaryRate = rate
+			return nil
+		},
+	},
+	"locale": {
+		get: func(c *Config) string { return c.Locale },
+		set: func(c *Config, value string) error {
+			if err := validateLocale(value); err != nil {
+				return err
+			}
+			c.Locale = value
+			return nil
+		},
+	},
+	"income": {
+		get: func(c *Config) string { return strconv.FormatFloat(c.Income, 'f', -1, 64) },
+		set: func(c *Config, value string) error {
+			income, err := strconv.ParseFloat(value, 64)
+			if err != nil || income < 0 {
+				return fmt.Errorf("invalid income '%s': must be a non-negative number", value)
+			}
+			c.Income = income
+			return nil
+		},
+	},
+	"compact_threshold": {
+		get: func(c *Config) string { return strconv.FormatFloat(c.CompactThreshold, 'f', -1, 64) },
+		set: func(c *Config, value string) error {
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil || threshold < 0 {
+				return fmt.Errorf("invalid compact_threshold '%s': must be a non-negative number", value)
+			}
+			c.CompactThreshold = threshold
+			return nil
+		},
+	},
+	"dup_check_window": {
+		get: func(c *Config) string { return strconv.Itoa(c.DupCheckWindow) },
+		set: func(c *Config, value string) error {
+			window, err := strconv.Atoi(value)
+			if err != nil || window < -1 {
+				return fmt.Errorf("invalid dup_check_window '%s': must be a number of days, or -1 to disable", value)
+			}
+			c.DupCheckWindow = window
+			return nil
+		},
+	},
+	"dup_check_category": {
+		get: func(c *Config) string { return strconv.FormatBool(c.DupCheckCategory) },
+		set: func(c *Config, value string) error {
+			match, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid dup_check_category '%s': must be true or false", value)
+			}
+			c.DupCheckCategory = match
+			return nil
+		},
+	},
+	"payday": {
+		get: func(c *Config) string { return strconv.Itoa(c.Payday) },
+		set: func(c *Config, value string) error {
+			payday, err := strconv.Atoi(value)
+			if err != nil || payday < 0 || payday > 31 {
+				return fmt.Errorf("invalid payday '%s': must be a day between 1 and 31, or 0 to unset", value)
+			}
+			c.Payday = payday
+			return nil
+		},
+	},
+	"strict_budgets": {
+		get: func(c *Config) string { return strconv.FormatBool(c.StrictBudgets) },
+		set: func(c *Config, value string) error {
+			strict, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid strict_budgets '%s': must be true or false", value)
+			}
+			c.StrictBudgets = strict
+			return nil
+		},
+	},
+	"category_required": {
+		get: func(c *Config) string { return strconv.FormatBool(c.CategoryRequired) },
+		set: func(c *Config, value string) error {
+			required, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid category_required '%s': must be true or false", value)
+			}
+			c.CategoryRequired = required
+			return nil
+		},
+	},
+	"category_aliases": {
+		get: func(c *Config) string { return formatCategoryAliases(c.CategoryAliases) },
+		set: func(c *Config, value string) error {
+			aliases, err := parseCategoryAliases(value)
+			if err != nil {
+				return err
+			}
+			c.CategoryAliases = aliases
+			return nil
+		},
+	},
+	"category_separator": {
+		get: func(c *Config) string { return c.CategorySeparator },
+		set: func(c *Config, value string) error {
+			if err := validateCategorySeparator(value); err != nil {
+				return err
+			}
+			c.CategorySeparator = value
+			return nil
+		},
+	},
+	"database.max_open_conns": {
+		get: func(c *Config) string { return strconv.Itoa(c.Database.MaxOpenConns) },
+		set: func(c *Config, value string) error {
+			conns, err := strconv.Atoi(value)
+			if err != nil || conns < 0 {
+				return fmt.Errorf("invalid database.max_open_conns '%s': must be a non-negative integer (0 for no limit)", value)
+			}
+			c.Database.MaxOpenConns = conns
+			return nil
+		},
+	},
+	"database.max_idle_conns": {
+		get: func(c *Config) string { return strconv.Itoa(c.Database.MaxIdleConns) },
+		set: func(c *Config, value string) error {
+			conns, err := strconv.Atoi(value)
+			if err != nil || conns < 0 {
+				return fmt.Errorf("invalid database.max_idle_conns '%s': must be a non-negative integer", value)
+			}
+			c.Database.MaxIdleConns = conns
+			return nil
+		},
+	},
+	"database.conn_max_lifetime": {
+		get: func(c *Config) string { return c.Database.ConnMaxLifetime.String() },
+		set: func(c *Config, value string) error {
+			lifetime, err := time.ParseDuration(value)
+			if err != nil || lifetime < 0 {
+				return fmt.Errorf("invalid database.conn_max_lifetime '%s': must be a duration such as 30m, or 0 for no limit", value)
+			}
+			c.Database.ConnMaxLifetime = lifetime
+			return nil
+		},
+	},
+	"status_glyph": {
+		get: func(c *Config) string { return c.StatusGlyph },
+		set: func(c *Config, value string) error {
+			if err := validateGlyph("status_glyph", value); err != nil {
+				return err
+			}
+			c.StatusGlyph = value
+			return nil
+		},
+	},
+	"bar_char": {
+		get: func(c *Config) string { return c.BarChar },
+		set: func(c *Config, value string) error {
+			if err := validateGlyph("bar_char", value); err != nil {
+				return err
+			}
+			c.BarChar = value
+			return nil
+		},
+	},
+	"post_add_hook": {
+		get: func(c *Config) string { return c.PostAddHook },
+		set: func(c *Config, value string) error {
+			c.PostAddHook = value
+			return nil
+		},
+	},
+	"webhook_url": {
+		get: func(c *Config) string { return c.WebhookURL },
+		set: func(c *Config, value string) error {
+			if err := validateWebhookURL(value); err != nil {
+				return err
+			}
+			c.WebhookURL = value
+			return nil
+		},
+	},
+	"webhook_events": {
+		get: func(c *Config) string { return strings.Join(c.WebhookEvents, ",") },
+		set: func(c *Config, value string) error {
+			var events []string
+			for _, event := range strings.Split(value, ",") {
+				events = append(events, strings.TrimSpace(event))
+			}
+			if err := validateWebhookEvents(events); err != nil {
+				return err
+			}
+			c.WebhookEvents = events
+			return nil
+		},
+	},
+	"timezone": {
+		get: func(c *Config) string { return c.Timezone },
+		set: func(c *Config, value string) error {
+			if _, err := time.LoadLocation(value); err != nil {
+				return fmt.Errorf("invalid timezone '%s': %v", value, err)
+			}
+			c.Timezone = value
+			return nil
+		},
+	},
+}
+
+func configPath() string {
+	return filepath.Join(monkeDir(), "config.toml")
+}
+
+func loadConfig() {
+	cfg = defaultConfig()
+	_, err := toml.DecodeFile(configPath(), &cfg)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("Error reading config file: %v", err)
+	}
+	if cfg.Currency != "" {
+		if err := validateCurrency(cfg.Currency); err != nil {
+			log.Fatalf("Error in config file: %v", err)
+		}
+	}
+	if cfg.Width <= 0 {
+		log.Fatalf("Error in config file: invalid width '%d': must be a positive integer", cfg.Width)
+	}
+
+	if cfg.Locale != "" {
+		if err := validateLocale(cfg.Locale); err != nil {
+			log.Fatalf("Error in config file: %v", err)
+		}
+	}
+	if cfg.SecondaryCurrency != "" {
+		if err := validateCurrency(cfg.SecondaryCurrency); err != nil {
+			log.Fatalf("Error in config file: %v", err)
+		}
+	}
+	if cfg.SecondaryRate < 0 {
+		log.Fatalf("Error in config file: invalid secondary_rate '%g': must be a non-negative number", cfg.SecondaryRate)
+	}
+	if cfg.Income < 0 {
+		log.Fatalf("Error in config file: invalid income '%g': must be a non-negative number", cfg.Income)
+	}
+	if err := validateCategorySeparator(cfg.CategorySeparator); err != nil {
+		log.Fatalf("Error in config file: %v", err)
+	}
+	if err := validateCategoryAliases(cfg.CategoryAliases); err != nil {
+		log.Fatalf("Error in config file: %v", err)
+	}
+	if cfg.DupCheckWindow < -1 {
+		log.Fatalf("Error in config file: invalid dup_check_window '%d': must be a number of days, or -1 to disable", cfg.DupCheckWindow)
+	}
+	if cfg.CompactThreshold < 0 {
+		log.Fatalf("Error in config file: invalid compact_threshold '%g': must be a non-negative number", cfg.CompactThreshold)
+	}
+	if cfg.Payday < 0 || cfg.Payday > 31 {
+		log.Fatalf("Error in config file: invalid payday '%d': must be a day between 1 and 31, or 0 to unset", cfg.Payday)
+	}
+	if cfg.Database.MaxOpenConns < 0 {
+		log.Fatalf("Error in config file: invalid database.max_open_conns '%d': must be a non-negative integer (0 for no limit)", cfg.Database.MaxOpenConns)
+	}
+	if cfg.Database.MaxIdleConns < 0 {
+		log.Fatalf("Error in config file: invalid database.max_idle_conns '%d': must be a non-negative integer", cfg.Database.MaxIdleConns)
+	}
+	if cfg.Database.ConnMaxLifetime < 0 {
+		log.Fatalf("Error in config file: invalid database.conn_max_lifetime '%s': must not be negative", cfg.Database.ConnMaxLifetime)
+	}
+	if err := validateWebhookURL(cfg.WebhookURL); err != nil {
+		log.Fatalf("Error in config file: %v", err)
+	}
+	if err := validateWebhookEvents(cfg.WebhookEvents); err != nil {
+		log.Fatalf("Error in config file: %v", err)
+	}
+	if cfg.ClearConfirmThreshold < 0 {
+		log.Fatalf("Error in config file: invalid clear_confirm_threshold '%d': must be a non-negative integer", cfg.ClearConfirmThreshold)
+	}
+
+	if asciiOutput {
+		statusIndicator, lineCharacter = "*", "#"
+	}
+	for _, glyph := range []struct {
+		key, configured, flag string
+		target                *string
+	}{
+		{"status_glyph", cfg.StatusGlyph, statusGlyphFlag, &statusIndicator},
+		{"bar_char", cfg.BarChar, barCharFlag, &lineCharacter},
+	} {
+		if glyph.configured != "" {
+			if err := validateGlyph(glyph.key, glyph.configured); err != nil {
+				log.Fatalf("Error in config file: %v", err)
+			}
+			if !asciiOutput {
+				*glyph.target = glyph.configured
+			}
+		}
+		if glyph.flag != "" {
+			if err := validateGlyph(glyph.key, glyph.flag); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			*glyph.target = glyph.flag
+		}
+	}
+
+	zone := cfg.Timezone
+	if timezoneFlag != "" {
+		zone = timezoneFlag
+	}
+	if zone != "" {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			log.Fatalf("Error: Invalid timezone '%s': %v", zone, err)
+		}
+		location = loc
+	}
+
+	nowOverride = time.Time{}
+	if nowFlag != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", nowFlag, location)
+		if err != nil {
+			log.Fatalf("Error: Invalid date '%s' for --now. Please use the YYYY-MM-DD format.", nowFlag)
+		}
+		nowOverride = parsed
+	}
+}
+
+// categoryRequired reports whether expenses must have a category, from
+// the config or for this run from --category-required. The flag is not
+// copied into cfg so that 'config set' never saves it.
+func categoryRequired() bool {
+	return cfg.CategoryRequired || categoryRequiredFlag
+}
+
+// currentTime is the time used to decide what "today" is, in the display
+// timezone. It is the start of the --now date when one is given.
+func currentTime() time.Time {
+	if !nowOverride.IsZero() {
+		return nowOverride
+	}
+	return time.Now().In(location)
+}
+
+func saveConfig() {
+	if err := os.MkdirAll(monkeDir(), 0o755); err != nil {
+		log.Fatalf("Error creating config directory: %v", err)
+	}
+	file, err := os.Create(configPath())
+	if err != nil {
+		log.Fatalf("Error writing config file: %v", err)
+	}
+	defer file.Close()
+
+	if err := toml.NewEncoder(file).Encode(cfg); err != nil {
+		log.Fatalf("Error writing config file: %v", err)
+	}
+}
+
+func validateCurrency(value string) error {
+	runes := []rune(value)
+	if len(runes) == 0 || len(runes) > 3 {
+		return fmt.Errorf("invalid currency '%s': must be a symbol of 1 to 3 characters", value)
+	}
+	for _, r := range runes {
+		if unicode.IsSpace(r) || unicode.IsDigit(r) {
+			return fmt.Errorf("invalid currency '%s': must not contain digits or spaces", value)
+		}
+	}
+	return nil
+}
+
+func validateCategorySeparator(value string) error {
+	if strings.TrimSpace(value) != value {
+		return fmt.Errorf("invalid category_separator '%s': must not contain leading or trailing spaces", value)
+	}
+	return nil
+}
+
+// validateGlyph checks that a status glyph or bar character is a single
+// visible character, since widths are computed per character.
+func validateGlyph(key, value string) error {
+	if utf8.RuneCountInString(value) != 1 || strings.TrimSpace(value) == "" {
+		return fmt.Errorf("invalid %s '%s': must be a single visible character", key, value)
+	}
+	return nil
+}
+
+func validateLocale(value string) error {
+	if _, ok := monthNames[value]; !ok {
+		return fmt.Errorf("unsupported locale '%s': must be one of %s", value, strings.Join(supportedLocales(), ", "))
+	}
+	return nil
+}
+
+func lookupConfigKey(name string) configKey {
+	key, ok := configKeys[name]
+	if !ok {
+		var names []string
+		for n := range configKeys {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		log.Fatalf("Error: Unknown config key '%s'. Valid keys: %s.", name, strings.Join(names, ", "))
+	}
+	return key
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read or change settings in the config file",
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a config key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		key := lookupConfigKey(args[0])
+		fmt.Println(key.get(&cfg))
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a config key and save the config file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		key := lookupConfigKey(args[0])
+		if err := key.set(&cfg, args[1]); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		saveConfig()
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+}
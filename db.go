@@ -2,58 +2,494 @@ package main
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 var (
-	db     *sql.DB
-	dbPath string
+	db         *sql.DB
+	dbPath     string
+	explainSQL bool
+
+	// readOnly opens the database with mode=ro and skips schema setup.
+	readOnly bool
+
+	// dbFlag is the database file given with --db, or memoryDSN for a
+	// throwaway in-memory database; empty uses monke.db in monkeDir.
+	// seedPath is an SQL dump replayed into the in-memory database.
+	dbFlag   string
+	seedPath string
+
+	// dbOnce guards db and dbPath so repeated or concurrent initDB calls
+	// open the database only once.
+	dbOnce sync.Once
 )
 
 type Expense struct {
-	ID       int
-	Title    string
-	Amount   float64
-	Day      int
-	Category string
+	ID       int     `json:"id"`
+	Title    string  `json:"title"`
+	Amount   float64 `json:"amount"`
+	Day      int     `json:"day"`
+	Category string  `json:"category"`
+	Time     string  `json:"time,omitempty"`
+
+	// CreatedAt is when the row was entered, as a UTC "YYYY-MM-DD HH:MM:SS"
+	// timestamp; empty for rows entered before it was recorded.
+	CreatedAt string `json:"created_at,omitempty"`
 }
 
 type CategoryTotal struct {
-	Name   string
-	Amount float64
+	Name    string  `json:"name"`
+	Amount  float64 `json:"amount"`
+	Percent float64 `json:"percent"`
 }
 
-func initDB() {
+func monkeDir() string {
 	currentUser, err := user.Current()
 	if err != nil {
 		log.Fatalf("Error getting current user: %v", err)
 	}
-	configDir := filepath.Join(currentUser.HomeDir, ".config", "monke")
-	dbPath = filepath.Join(configDir, "monke.db")
-	err = os.MkdirAll(configDir, 0o755)
-	if err != nil {
-		log.Fatalf("Error creating config directory: %v", err)
+	return filepath.Join(currentUser.HomeDir, ".config", "monke")
+}
+
+const createExpensesTableSQL = `CREATE TABLE IF NOT EXISTS expenses (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"title" TEXT,
+	"amount" REAL,
+	"day" INTEGER,
+	"category" TEXT,
+	"time" TEXT,
+	"created_at" TEXT DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createBudgetsTableSQL = `CREATE TABLE IF NOT EXISTS budgets (
+	"category" TEXT NOT NULL PRIMARY KEY,
+	"amount" REAL NOT NULL,
+	"percent" REAL
+);`
+
+const createSnapshotsTableSQL = `CREATE TABLE IF NOT EXISTS snapshots (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"taken_at" TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"total" REAL NOT NULL
+);`
+
+const createSnapshotCategoriesTableSQL = `CREATE TABLE IF NOT EXISTS snapshot_categories (
+	"snapshot_id" INTEGER NOT NULL REFERENCES snapshots(id) ON DELETE CASCADE,
+	"category" TEXT NOT NULL,
+	"amount" REAL NOT NULL,
+	PRIMARY KEY (snapshot_id, category)
+);`
+
+// schemaTables pairs each table with the statement creating it.
+var schemaTables = []struct {
+	name      string
+	createSQL string
+}{
+	{"expenses", createExpensesTableSQL},
+	{"budgets", createBudgetsTableSQL},
+	{"snapshots", createSnapshotsTableSQL},
+	{"snapshot_categories", createSnapshotCategoriesTableSQL},
+}
+
+// initDB opens the database and brings its schema up to date. It is safe
+// to call more than once.
+func initDB() {
+	dbOnce.Do(openDB)
+}
+
+// memoryDSN opens an in-memory database that is discarded on exit.
+const memoryDSN = ":memory:"
+
+func openDB() {
+	dbPath = dbFlag
+	if dbPath == memoryDSN {
+		openMemoryDB()
+		return
+	}
+	if seedPath != "" {
+		log.Fatal("Error: --seed requires an in-memory database (--ephemeral or --db :memory:).")
+	}
+	if dbPath == "" {
+		dbPath = filepath.Join(monkeDir(), "monke.db")
+	}
+	var err error
+	if readOnly {
+		if _, err := os.Stat(dbPath); err != nil {
+			log.Fatalf("Error opening database read-only: %v", err)
+		}
+		db, err = sql.Open("sqlite3", readOnlyDSN(dbPath))
+		if err != nil {
+			log.Fatalf("Error opening database: %v", err)
+		}
+		configurePool(db)
+		fillMissingSchema()
+		detectFTS()
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		log.Fatalf("Error creating database directory: %v", err)
 	}
 	db, err = sql.Open("sqlite3", dbPath)
 	if err != nil {
 		log.Fatalf("Error opening database: %v", err)
 	}
+	configurePool(db)
+	setupSchema()
+}
+
+// readOnlyDSN returns the URI that opens the database at path read-only,
+// escaping characters such as '?' and '#' that would otherwise end the path.
+// The path is made absolute, as a URI has no relative form with a scheme.
+func readOnlyDSN(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path), RawQuery: "mode=ro"}
+	return u.String()
+}
+
+// openMemoryDB opens an empty in-memory database, optionally seeded from
+// an SQL dump, so that nothing the command does is persisted.
+func openMemoryDB() {
+	var err error
+	db, err = sql.Open("sqlite3", memoryDSN)
+	if err != nil {
+		log.Fatalf("Error opening in-memory database: %v", err)
+	}
+	// Every connection to :memory: is a separate database that is lost
+	// when the connection closes, so keep exactly one open for good.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	setupSchema()
 
-	createTableSQL := `CREATE TABLE IF NOT EXISTS expenses (
-		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-		"title" TEXT,
-		"amount" REAL,
-		"day" INTEGER,
-		"category" TEXT
-	);`
+	if seedPath == "" {
+		return
+	}
+	var dump []byte
+	if seedPath == "-" {
+		dump, err = io.ReadAll(os.Stdin)
+	} else {
+		dump, err = os.ReadFile(seedPath)
+	}
+	if err != nil {
+		log.Fatalf("Error reading seed dump: %v", err)
+	}
+	if err := replayDump(db, string(dump)); err != nil {
+		log.Fatalf("Error replaying seed dump: %v", err)
+	}
+}
 
-	_, err = db.Exec(createTableSQL)
+// setupSchema creates missing tables and brings older databases up to date.
+func setupSchema() {
+	_, err := db.Exec(createExpensesTableSQL)
 	if err != nil {
 		log.Fatalf("Error creating table: %v", err)
 	}
+
+	_, err = db.Exec(createBudgetsTableSQL)
+	if err != nil {
+		log.Fatalf("Error creating budgets table: %v", err)
+	}
+
+	_, err = db.Exec(createSnapshotsTableSQL + createSnapshotCategoriesTableSQL)
+	if err != nil {
+		log.Fatalf("Error creating snapshots tables: %v", err)
+	}
+
+	migrateDB()
+	setupFTS()
+}
+
+// configurePool applies the [database] config section to the connection
+// pool; see DatabaseConfig for why a single connection is the default.
+func configurePool(conn *sql.DB) {
+	conn.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	conn.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	conn.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+}
+
+// addedColumns lists columns added after the initial schema, so that
+// databases created by older versions can be upgraded in place.
+var addedColumns = []struct {
+	table      string
+	name       string
+	definition string
+}{
+	{"expenses", "time", "TEXT"},
+	// SQLite cannot add a column with a CURRENT_TIMESTAMP default, so
+	// insertExpense sets it explicitly.
+	{"expenses", "created_at", "TEXT"},
+	{"budgets", "percent", "REAL"},
+}
+
+func migrateDB() {
+	existing := make(map[string]map[string]struct{})
+	for _, col := range addedColumns {
+		if _, ok := existing[col.table]; !ok {
+			columns, err := tableColumns(db, col.table)
+			if err != nil {
+				log.Fatalf("Error reading table schema: %v", err)
+			}
+			existing[col.table] = columns
+		}
+		if _, ok := existing[col.table][col.name]; ok {
+			continue
+		}
+		_, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN "%s" %s`, col.table, col.name, col.definition))
+		if err != nil {
+			log.Fatalf("Error migrating table: %v", err)
+		}
+	}
+}
+
+// fillMissingSchema lets a read-only database created by an older version
+// be queried without migrating it. Missing tables are stood in for by
+// empty temporary ones, and tables lacking addedColumns are shadowed by
+// temporary views adding them as NULL, as migrateDB would have.
+func fillMissingSchema() {
+	var statements []string
+	exists := make(map[string]bool)
+	for _, table := range schemaTables {
+		columns, err := tableColumns(db, table.name)
+		if err != nil {
+			log.Fatalf("Error reading table schema: %v", err)
+		}
+		exists[table.name] = len(columns) > 0
+		if !exists[table.name] {
+			statements = append(statements, strings.Replace(table.createSQL, "CREATE TABLE", "CREATE TEMP TABLE", 1))
+		}
+	}
+
+	missing := make(map[string][]string)
+	var tables []string
+	for _, col := range addedColumns {
+		if !exists[col.table] {
+			continue
+		}
+		columns, err := tableColumns(db, col.table)
+		if err != nil {
+			log.Fatalf("Error reading table schema: %v", err)
+		}
+		if _, ok := columns[col.name]; ok {
+			continue
+		}
+		if _, seen := missing[col.table]; !seen {
+			tables = append(tables, col.table)
+		}
+		missing[col.table] = append(missing[col.table], fmt.Sprintf(`NULL AS "%s"`, col.name))
+	}
+	for _, table := range tables {
+		statements = append(statements, fmt.Sprintf("CREATE TEMP VIEW %s AS SELECT *, %s FROM main.%s", table, strings.Join(missing[table], ", "), table))
+	}
+	if len(statements) == 0 {
+		return
+	}
+
+	// Temporary tables and views belong to the connection that created
+	// them.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			log.Fatalf("Error reading older database schema: %v", err)
+		}
+	}
+}
+
+// expenseTableColumns returns the set of column names of the expenses table
+// in the given database.
+func expenseTableColumns(conn *sql.DB) (map[string]struct{}, error) {
+	return tableColumns(conn, "expenses")
+}
+
+// tableColumns returns the set of column names of a table in the given
+// database. table must be a trusted name, as it is interpolated.
+func tableColumns(conn *sql.DB, table string) (map[string]struct{}, error) {
+	rows, err := conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]struct{})
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return nil, err
+		}
+		columns[name] = struct{}{}
+	}
+	return columns, rows.Err()
+}
+
+// selectExpensesSQL selects the columns scanned by queryExpenses.
+const selectExpensesSQL = "SELECT id, title, amount, day, category, time, created_at FROM expenses"
+
+// orderByDueSQL orders expenses by when they are due.
+const orderByDueSQL = " ORDER BY day ASC, time ASC"
+
+// stableTiebreakSQL extends orderByDueSQL for 'ls --sort-stable', so that
+// expenses due at the same time are listed identically from run to run.
+const stableTiebreakSQL = ", id ASC"
+
+// queryExpenses runs a query built on selectExpensesSQL and returns the rows.
+func queryExpenses(query string, args ...any) []Expense {
+	explain(query, args...)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such column: day") {
+			log.Fatalf("Error: Database schema mismatch. Clear the database with 'monke clear'.")
+		}
+		log.Fatalf("Error querying expenses: %v", err)
+	}
+	defer rows.Close()
+
+	var expenses []Expense
+	for rows.Next() {
+		var exp Expense
+		var category, timeOfDay, createdAt sql.NullString
+
+		err := rows.Scan(&exp.ID, &exp.Title, &exp.Amount, &exp.Day, &category, &timeOfDay, &createdAt)
+		if err != nil {
+			log.Printf("Error scanning row: %v", err)
+			continue
+		}
+		exp.Category = category.String
+		exp.Time = timeOfDay.String
+		exp.CreatedAt = createdAt.String
+
+		expenses = append(expenses, exp)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Fatalf("Error iterating rows: %v", err)
+	}
+	return expenses
+}
+
+// printNoExpenses is the shared empty state of read commands.
+func printNoExpenses() {
+	fmt.Println(noExpensesMessage())
+}
+
+// noExpensesMessage tells an empty database apart from filters that
+// matched nothing.
+func noExpensesMessage() string {
+	var count int
+	query := "SELECT COUNT(*) FROM expenses"
+	explain(query)
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		log.Fatalf("Error counting expenses: %v", err)
+	}
+	if count == 0 {
+		return `No expenses yet. Add one with 'monke add "Rent 1200 @1 #housing"'.`
+	}
+	return "No expenses found."
+}
+
+// categoryLabel returns the name shown for a category, which is
+// "Uncategorized" for expenses without one.
+func categoryLabel(category string) string {
+	if category == "" {
+		return "Uncategorized"
+	}
+	return category
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertExpense inserts exp and returns the id it was given.
+func insertExpense(ex execer, exp Expense) (int, error) {
+	var timeValue any
+	if exp.Time != "" {
+		timeValue = exp.Time
+	}
+	insertSQL := `INSERT INTO expenses(title, amount, day, category, time, created_at) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	explain(insertSQL, exp.Title, exp.Amount, exp.Day, exp.Category, timeValue)
+	result, err := ex.Exec(insertSQL, exp.Title, exp.Amount, exp.Day, exp.Category, timeValue)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// updateExpense overwrites every field of the expense with exp.ID.
+func updateExpense(ex execer, exp Expense) error {
+	var timeValue any
+	if exp.Time != "" {
+		timeValue = exp.Time
+	}
+	updateSQL := `UPDATE expenses SET title = ?, amount = ?, day = ?, category = ?, time = ? WHERE id = ?`
+	explain(updateSQL, exp.Title, exp.Amount, exp.Day, exp.Category, timeValue, exp.ID)
+	_, err := ex.Exec(updateSQL, exp.Title, exp.Amount, exp.Day, exp.Category, timeValue, exp.ID)
+	return err
+}
+
+const (
+	busyRetries      = 5
+	busyInitialDelay = 50 * time.Millisecond
+)
+
+// retryBusy runs fn, retrying with exponential backoff while SQLite reports
+// the database as busy or locked.
+func retryBusy(fn func() error) error {
+	delay := busyInitialDelay
+	var err error
+	for attempt := 1; attempt <= busyRetries; attempt++ {
+		err = fn()
+		if !isBusyError(err) {
+			return err
+		}
+		if attempt < busyRetries {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return fmt.Errorf("database is locked, gave up after %d attempts: %w", busyRetries, err)
+}
+
+func isBusyError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
+
+// explain prints a statement and its bound parameters to stderr when
+// --explain is set, keeping stdout clean for command output.
+func explain(query string, args ...any) {
+	if !explainSQL {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "SQL: %s\n", query)
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "     args: %v\n", args)
+	}
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// diffKey identifies the same expense in two databases; amounts are
+// compared separately.
+type diffKey struct {
+	title    string
+	day      int
+	category string
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <other.db>",
+	Short: "Show how the expenses in another monke database differ from these",
+	Long: `Compare the expenses in this database with another one, e.g. before a
+merge. Expenses are matched on title, day and category:
+
+  - only in this database
+  + only in the other database
+  ~ in both, with a different amount (this → other)`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		other := openOtherDB(args[0])
+		defer other.Close()
+
+		local := groupByDiffKey(readDiffExpenses(db, "this database"))
+		remote := groupByDiffKey(readDiffExpenses(other, args[0]))
+
+		keys := make(map[diffKey]struct{})
+		for key := range local {
+			keys[key] = struct{}{}
+		}
+		for key := range remote {
+			keys[key] = struct{}{}
+		}
+		sorted := make([]diffKey, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].day != sorted[j].day {
+				return sorted[i].day < sorted[j].day
+			}
+			if sorted[i].title != sorted[j].title {
+				return sorted[i].title < sorted[j].title
+			}
+			return sorted[i].category < sorted[j].category
+		})
+
+		onlyLocal, onlyRemote, changed := 0, 0, 0
+		for _, key := range sorted {
+			ours, theirs := unmatchedAmounts(local[key], remote[key])
+			label := fmt.Sprintf("%s (day %d, %s)", key.title, key.day, categoryLabel(key.category))
+			for len(ours) > 0 && len(theirs) > 0 {
+				fmt.Println(colorize(colorToday, fmt.Sprintf("~ %s: %s → %s", label, formatAmount(ours[0]), formatAmount(theirs[0]))))
+				ours, theirs = ours[1:], theirs[1:]
+				changed++
+			}
+			for _, amount := range ours {
+				fmt.Println(colorize(colorDebit, fmt.Sprintf("- %s: %s", label, formatAmount(amount))))
+				onlyLocal++
+			}
+			for _, amount := range theirs {
+				fmt.Println(colorize(colorCredit, fmt.Sprintf("+ %s: %s", label, formatAmount(amount))))
+				onlyRemote++
+			}
+		}
+
+		if onlyLocal+onlyRemote+changed == 0 {
+			fmt.Println("No differences.")
+			return
+		}
+		fmt.Printf("\n%d only here, %d only in %s, %d with different amounts.\n", onlyLocal, onlyRemote, args[0], changed)
+	},
+}
+
+// readDiffExpenses reads the expenses of a database as stored, failing on
+// a row whose amount or day cannot be read.
+func readDiffExpenses(source *sql.DB, name string) []Expense {
+	expenses, rowErrors := readMergeExpenses(source, nil)
+	if len(rowErrors) > 0 {
+		log.Fatalf("Error reading %s: row %d: %v", name, rowErrors[0].line, rowErrors[0].err)
+	}
+	return expenses
+}
+
+// groupByDiffKey collects the amounts of expenses sharing a diffKey.
+func groupByDiffKey(expenses []Expense) map[diffKey][]float64 {
+	groups := make(map[diffKey][]float64)
+	for _, exp := range expenses {
+		key := diffKey{exp.Title, exp.Day, exp.Category}
+		groups[key] = append(groups[key], exp.Amount)
+	}
+	return groups
+}
+
+// unmatchedAmounts drops the amounts found on both sides, leaving those
+// that differ, each sorted ascending so they pair up predictably.
+func unmatchedAmounts(ours, theirs []float64) ([]float64, []float64) {
+	remaining := make(map[float64]int)
+	for _, amount := range theirs {
+		remaining[amount]++
+	}
+	var onlyOurs []float64
+	for _, amount := range ours {
+		if remaining[amount] > 0 {
+			remaining[amount]--
+			continue
+		}
+		onlyOurs = append(onlyOurs, amount)
+	}
+	var onlyTheirs []float64
+	for _, amount := range theirs {
+		if remaining[amount] > 0 {
+			remaining[amount]--
+			onlyTheirs = append(onlyTheirs, amount)
+		}
+	}
+	sort.Float64s(onlyOurs)
+	sort.Float64s(onlyTheirs)
+	return onlyOurs, onlyTheirs
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// allowedDumpStatements are the statements 'export --format sql' writes.
+// A dump is untrusted input, so anything else, such as ATTACH DATABASE,
+// PRAGMA or VACUUM INTO, which can touch other files, is refused.
+var allowedDumpStatements = []*regexp.Regexp{
+	regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+(IF\s+NOT\s+EXISTS\s+)?"?expenses"?\s*\(`),
+	regexp.MustCompile(`(?is)^INSERT\s+INTO\s+"?expenses"?\s*(\([^)]*\))?\s*VALUES\s*\(`),
+}
+
+// replayDump executes an SQL dump written by 'export --format sql' one
+// statement at a time, after checking that every statement is allowed.
+func replayDump(ex execer, dump string) error {
+	statements := splitSQLStatements(dump)
+	for i, statement := range statements {
+		allowed := false
+		for _, pattern := range allowedDumpStatements {
+			if pattern.MatchString(statement) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("statement %d is not allowed in a dump; only CREATE TABLE expenses and INSERT INTO expenses are accepted", i+1)
+		}
+	}
+	for i, statement := range statements {
+		if _, err := ex.Exec(statement); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// splitSQLStatements splits a dump on the semicolons that end statements,
+// skipping those inside quoted strings and identifiers. Comments are
+// dropped and blank statements left out.
+func splitSQLStatements(dump string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(dump); i++ {
+		c := dump[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// A doubled quote inside a string closes and reopens it,
+			// which copies it through unchanged.
+			end := strings.IndexByte(dump[i+1:], c)
+			if end < 0 {
+				current.WriteString(dump[i:])
+				i = len(dump)
+				break
+			}
+			current.WriteString(dump[i : i+end+2])
+			i += end + 1
+		case c == '-' && strings.HasPrefix(dump[i:], "--"):
+			end := strings.IndexByte(dump[i:], '\n')
+			if end < 0 {
+				i = len(dump)
+				break
+			}
+			current.WriteByte(' ')
+			i += end - 1
+		case c == '/' && strings.HasPrefix(dump[i:], "/*"):
+			end := strings.Index(dump[i+2:], "*/")
+			if end < 0 {
+				i = len(dump)
+				break
+			}
+			current.WriteByte(' ')
+			i += end + 3
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
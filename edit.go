@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+const strikethrough = "\033[9m"
+
+var editCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Change fields of an existing expense",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireWritable(cmd)
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatalf("Error: Invalid expense ID '%s'.", args[0])
+		}
+
+		found := queryExpenses(selectExpensesSQL+" WHERE id = ?", id)
+		if len(found) == 0 {
+			log.Fatalf("Error: No expense with ID %d.", id)
+		}
+		before := found[0]
+		after := before
+
+		if cmd.Flags().Changed("title") {
+			after.Title, _ = cmd.Flags().GetString("title")
+		}
+		if cmd.Flags().Changed("amount") {
+			amountExpr, _ := cmd.Flags().GetString("amount")
+			after.Amount, err = evalAmount(amountExpr)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+		if cmd.Flags().Changed("day") {
+			after.Day, _ = cmd.Flags().GetInt("day")
+		}
+		if cmd.Flags().Changed("category") {
+			category, _ := cmd.Flags().GetString("category")
+			after.Category = resolveCategory(category)
+		}
+		if cmd.Flags().Changed("time") {
+			after.Time, _ = cmd.Flags().GetString("time")
+		}
+		if err := validateExpense(&after); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		changes := expenseDiff(before, after)
+		if len(changes) == 0 {
+			fmt.Println("No changes.")
+			return
+		}
+
+		err = retryBusy(func() error {
+			return updateExpense(db, after)
+		})
+		if err != nil {
+			log.Fatalf("Error updating expense: %v", err)
+		}
+
+		fmt.Printf("Updated expense %d:\n", id)
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+		notifyWebhook(webhookPayload{Event: "edit", Expense: &after})
+	},
+}
+
+// expenseDiff describes each field that differs between two versions of an
+// expense, with the old value struck through in red and the new in green.
+func expenseDiff(before, after Expense) []string {
+	fields := []struct {
+		name               string
+		oldValue, newValue string
+	}{
+		{"Title", before.Title, after.Title},
+		{"Amount", formatAmount(before.Amount), formatAmount(after.Amount)},
+		{"Day", strconv.Itoa(before.Day), strconv.Itoa(after.Day)},
+		{"Category", categoryLabel(before.Category), categoryLabel(after.Category)},
+		{"Time", before.Time, after.Time},
+	}
+
+	var changes []string
+	for _, field := range fields {
+		if field.oldValue == field.newValue {
+			continue
+		}
+		oldValue, newValue := field.oldValue, field.newValue
+		if oldValue == "" {
+			oldValue = "(none)"
+		}
+		if newValue == "" {
+			newValue = "(none)"
+		}
+		changes = append(changes, fmt.Sprintf("  %s: %s → %s", field.name, colorize(strikethrough+colorOverBudget, oldValue), colorize(colorCredit, newValue)))
+	}
+	return changes
+}
+
+func init() {
+	editCmd.Flags().StringP("title", "t", "", "New title")
+	editCmd.Flags().StringP("amount", "a", "", "New amount, or an expression like 3*4.50")
+	editCmd.Flags().BoolVar(&decimalComma, "decimal-comma", false, "Read the amount with a comma as the decimal separator, e.g. 1.234,50")
+	editCmd.Flags().IntP("day", "d", 0, "New day of the month (1-28)")
+	editCmd.Flags().StringP("category", "c", "", "New category (\"\" removes it unless category_required is set)")
+	editCmd.Flags().String("time", "", "New time of day (HH:MM, 24-hour; \"\" removes it)")
+}
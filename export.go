@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// exportColumns are the columns export can write, named so that the file
+// can be read back with import.
+var exportColumns = []string{"id", "title", "amount", "day", "category", "time", "created_at"}
+
+var exportFormats = []string{"csv", "tsv", "json", "sql", "prometheus"}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export expenses as CSV, TSV, JSON, SQL or Prometheus metrics",
+	Run: func(cmd *cobra.Command, _ []string) {
+		outPath, _ := cmd.Flags().GetString("out")
+		appendMode, _ := cmd.Flags().GetBool("append")
+		format, _ := cmd.Flags().GetString("format")
+		columnList, _ := cmd.Flags().GetString("columns")
+		utc, _ := cmd.Flags().GetBool("utc")
+
+		if !slices.Contains(exportFormats, format) {
+			log.Fatalf("Error: Invalid format '%s'. Valid formats: %s.", format, strings.Join(exportFormats, ", "))
+		}
+		if appendMode && outPath == "" {
+			log.Fatal("Error: --append requires --out.")
+		}
+		if appendMode && format == "json" {
+			log.Fatal("Error: --append is not supported for JSON, which must be a single document.")
+		}
+		if appendMode && format == "prometheus" {
+			log.Fatal("Error: --append is not supported for Prometheus, which must list each metric once.")
+		}
+		if format == "sql" && columnList != "" {
+			log.Fatal("Error: --columns is not supported for SQL, which always writes every column.")
+		}
+		if format == "prometheus" && columnList != "" {
+			log.Fatal("Error: --columns is not supported for Prometheus, which writes totals rather than expenses.")
+		}
+		if format == "sql" && cmd.Flags().Changed("local") {
+			log.Fatal("Error: --local is not supported for SQL, which writes timestamps in UTC as the database stores them.")
+		}
+		timestampLocation := location
+		if utc {
+			timestampLocation = time.UTC
+		}
+		columns, err := parseExportColumns(columnList)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		filter := readFilterFlags(cmd)
+		where, args := filter.where()
+		expenses := filter.apply(queryExpenses(selectExpensesSQL+where+" ORDER BY id ASC", args...))
+		// The empty state goes to stderr so that the exported document
+		// stays valid, e.g. a CSV header or [], when it is piped on.
+		if len(expenses) == 0 {
+			fmt.Fprintln(os.Stderr, noExpensesMessage())
+		}
+
+		var out io.Writer = os.Stdout
+		writeHeader := true
+		if outPath != "" {
+			flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			if appendMode {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+			file, err := os.OpenFile(outPath, flags, 0o644)
+			if err != nil {
+				log.Fatalf("Error opening export file: %v", err)
+			}
+			defer file.Close()
+
+			if appendMode {
+				info, err := file.Stat()
+				if err != nil {
+					log.Fatalf("Error reading export file: %v", err)
+				}
+				writeHeader = info.Size() == 0
+			}
+			out = file
+		}
+
+		switch format {
+		case "json":
+			err = writeExpensesJSON(out, expenses, columns, timestampLocation)
+		case "sql":
+			err = writeExpensesSQL(out, expenses, writeHeader)
+		case "prometheus":
+			err = writeExpensesPrometheus(out, expenses)
+		case "tsv":
+			err = writeExpensesCSV(out, expenses, columns, '\t', writeHeader, timestampLocation)
+		default:
+			err = writeExpensesCSV(out, expenses, columns, ',', writeHeader, timestampLocation)
+		}
+		if err != nil {
+			log.Fatalf("Error writing %s: %v", strings.ToUpper(format), err)
+		}
+
+		if outPath != "" {
+			fmt.Printf("Exported %d expenses to %s.\n", len(expenses), outPath)
+		}
+	},
+}
+
+// parseExportColumns parses a comma-separated list of column names,
+// defaulting to all exportColumns.
+func parseExportColumns(columnList string) ([]string, error) {
+	if columnList == "" {
+		return exportColumns, nil
+	}
+	var columns []string
+	for _, name := range strings.Split(columnList, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(exportColumns, name) {
+			return nil, fmt.Errorf("unknown column '%s', valid columns: %s", name, strings.Join(exportColumns, ", "))
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// exportValue returns the value of a column for an expense, with the entry
+// timestamp converted to loc.
+func exportValue(exp Expense, column string, loc *time.Location) any {
+	switch column {
+	case "id":
+		return exp.ID
+	case "title":
+		return exp.Title
+	case "amount":
+		return exp.Amount
+	case "day":
+		return exp.Day
+	case "category":
+		return exp.Category
+	case "time":
+		return exp.Time
+	case "created_at":
+		return exportTimestamp(exp.CreatedAt, loc)
+	}
+	return nil
+}
+
+// exportTimestamp converts a stored UTC timestamp to loc as RFC 3339, whose
+// offset keeps it unambiguous wherever the file is read. Rows entered before
+// timestamps were recorded stay empty.
+func exportTimestamp(stored string, loc *time.Location) string {
+	if stored == "" {
+		return ""
+	}
+	t, err := time.ParseInLocation(time.DateTime, stored, time.UTC)
+	if err != nil {
+		return stored
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+func writeExpensesCSV(w io.Writer, expenses []Expense, columns []string, comma rune, writeHeader bool, loc *time.Location) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if writeHeader {
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+	}
+	for _, exp := range expenses {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			switch value := exportValue(exp, column, loc).(type) {
+			case float64:
+				record[i] = strconv.FormatFloat(value, 'f', -1, 64)
+			default:
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// orderedRecord is a JSON object whose keys keep the order of the columns.
+type orderedRecord struct {
+	columns []string
+	values  []any
+}
+
+func (r orderedRecord) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range r.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		value, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func writeExpensesJSON(w io.Writer, expenses []Expense, columns []string, loc *time.Location) error {
+	records := make([]orderedRecord, 0, len(expenses))
+	for _, exp := range expenses {
+		record := orderedRecord{columns: columns}
+		for _, column := range columns {
+			record.values = append(record.values, exportValue(exp, column, loc))
+		}
+		records = append(records, record)
+	}
+	_, err := fmt.Fprintln(w, string(marshalJSON(records)))
+	return err
+}
+
+// writeExpensesSQL writes expenses as INSERT statements, preceded by the
+// table schema, so the dump can be replayed with sqlite3 or 'import --sql'.
+func writeExpensesSQL(w io.Writer, expenses []Expense, writeSchema bool) error {
+	if writeSchema {
+		if _, err := fmt.Fprintln(w, createExpensesTableSQL); err != nil {
+			return err
+		}
+	}
+	for _, exp := range expenses {
+		timeValue, createdAt := "NULL", "NULL"
+		if exp.Time != "" {
+			timeValue = sqlQuote(exp.Time)
+		}
+		if exp.CreatedAt != "" {
+			createdAt = sqlQuote(exp.CreatedAt)
+		}
+		_, err := fmt.Fprintf(w, "INSERT INTO expenses(id, title, amount, day, category, time, created_at) VALUES (%d, %s, %s, %d, %s, %s, %s);\n",
+			exp.ID, sqlQuote(exp.Title), strconv.FormatFloat(exp.Amount, 'f', -1, 64), exp.Day, sqlQuote(exp.Category), timeValue, createdAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeExpensesPrometheus writes spending totals as gauges in the Prometheus
+// text format, e.g. for the node_exporter textfile collector.
+func writeExpensesPrometheus(w io.Writer, expenses []Expense) error {
+	totalAmount := 0.0
+	categoryTotalsMap := make(map[string]float64)
+	for _, exp := range expenses {
+		categoryTotalsMap[categoryLabel(exp.Category)] += exp.Amount
+		totalAmount += exp.Amount
+	}
+	summary := newSummaryJSON(len(expenses), totalAmount, categoryTotalsMap)
+	escapeLabel := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
+	formatValue := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	var b strings.Builder
+	b.WriteString("# HELP monke_total_amount Total amount of all monthly expenses.\n")
+	b.WriteString("# TYPE monke_total_amount gauge\n")
+	fmt.Fprintf(&b, "monke_total_amount %s\n", formatValue(summary.Total))
+	b.WriteString("# HELP monke_expense_count Number of monthly expenses.\n")
+	b.WriteString("# TYPE monke_expense_count gauge\n")
+	fmt.Fprintf(&b, "monke_expense_count %d\n", summary.Count)
+	b.WriteString("# HELP monke_category_amount Total amount of monthly expenses per category.\n")
+	b.WriteString("# TYPE monke_category_amount gauge\n")
+	for _, cat := range summary.Categories {
+		fmt.Fprintf(&b, "monke_category_amount{category=\"%s\"} %s\n", escapeLabel(cat.Name), formatValue(cat.Amount))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sqlQuote returns s as an SQL string literal.
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func init() {
+	exportCmd.Flags().StringP("out", "o", "", "Write to this file instead of stdout")
+	exportCmd.Flags().Bool("append", false, "Append to the --out file, writing the header only if it is empty")
+	exportCmd.Flags().StringP("format", "f", "csv", "Output format: csv, tsv, json, sql or prometheus")
+	addFilterFlags(exportCmd)
+	exportCmd.Flags().String("columns", "", "Comma-separated columns to write, in order (default all)")
+	exportCmd.Flags().Bool("utc", false, "Write created_at timestamps in UTC")
+	exportCmd.Flags().Bool("local", false, "Write created_at timestamps in the local timezone, or --tz (default; SQL dumps always use UTC)")
+
+	exportCmd.MarkFlagsMutuallyExclusive("utc", "local")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// decimalComma makes amounts use a comma as the decimal separator and dots
+// as thousands separators, e.g. "1.234,50"; see --decimal-comma.
+var decimalComma bool
+
+// decimalCommaNumber matches a number written with a decimal comma.
+var decimalCommaNumber = regexp.MustCompile(`[0-9.,]+`)
+
+// dotGroupedNumber matches a decimal comma number whose dots separate
+// groups of exactly three digits, e.g. "1.234.567,50".
+var dotGroupedNumber = regexp.MustCompile(`^\d{1,3}(\.\d{3})+(,\d+)?$`)
+
+// evalAmount evaluates a simple arithmetic expression such as "3*4.50" or
+// "10+5.25". Only numbers, + - * /, unary signs and parentheses are allowed.
+func evalAmount(input string) (float64, error) {
+	p := &exprParser{input: strings.ReplaceAll(input, " ", "")}
+	if p.input == "" {
+		return 0, errors.New("amount is empty")
+	}
+	if decimalComma {
+		converted, err := fromDecimalComma(p.input)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount '%s': %w", input, err)
+		}
+		p.input = converted
+	}
+
+	value, err := p.parseExpr()
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount '%s': %w", input, err)
+	}
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("invalid amount '%s': unexpected '%c'", input, p.input[p.pos])
+	}
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("invalid amount '%s': result is not a finite number", input)
+	}
+	return value, nil
+}
+
+// fromDecimalComma rewrites every number in input from the decimal comma
+// form to the dot form the parser reads, e.g. "1.234,50" to "1234.50". A
+// dot is only accepted between groups of three digits; anything else, such
+// as "12.50", is rejected rather than read as 1250.
+func fromDecimalComma(input string) (string, error) {
+	var err error
+	converted := decimalCommaNumber.ReplaceAllStringFunc(input, func(number string) string {
+		switch {
+		case err != nil:
+		case strings.Count(number, ",") > 1:
+			err = fmt.Errorf("'%s' has more than one decimal comma", number)
+		case strings.Contains(number, ".") && !dotGroupedNumber.MatchString(number):
+			err = fmt.Errorf("'%s' uses '.' other than between groups of three digits; write decimals with ','", number)
+		}
+		return strings.Replace(strings.ReplaceAll(number, ".", ""), ",", ".", 1)
+	})
+	return converted, err
+}
+
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func (p *exprParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+// parseExpr handles addition and subtraction.
+func (p *exprParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+}
+
+// parseTerm handles multiplication and division.
+func (p *exprParser) parseTerm() (float64, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return 0, err
+		}
+		if op == '*' {
+			left *= right
+		} else {
+			if right == 0 {
+				return 0, errors.New("division by zero")
+			}
+			left /= right
+		}
+	}
+}
+
+// parseFactor handles numbers, unary signs and parenthesized expressions.
+func (p *exprParser) parseFactor() (float64, error) {
+	switch c := p.peek(); {
+	case c == '-' || c == '+':
+		p.pos++
+		value, err := p.parseFactor()
+		if c == '-' {
+			value = -value
+		}
+		return value, err
+	case c == '(':
+		p.pos++
+		value, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, errors.New("missing closing parenthesis")
+		}
+		p.pos++
+		return value, nil
+	}
+
+	start := p.pos
+	for c := p.peek(); (c >= '0' && c <= '9') || c == '.'; c = p.peek() {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos >= len(p.input) {
+			return 0, errors.New("unexpected end of expression")
+		}
+		return 0, fmt.Errorf("unexpected '%c'", p.input[p.pos])
+	}
+	return strconv.ParseFloat(p.input[start:p.pos], 64)
+}
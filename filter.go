@@ -0,0 +1,175 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// expenseFilter narrows the rows selected from the expenses table.
+type expenseFilter struct {
+	// categories matches any of the listed categories. An empty name
+	// matches uncategorized expenses.
+	categories []string
+
+	// search matches expenses whose title contains the term.
+	search string
+
+	// day matches expenses due on this day of the month; 0 matches any day.
+	day int
+
+	// fromDay and toDay match expenses due within a range of days,
+	// wrapping past the end of the month when fromDay is after toDay.
+	// 0 leaves the range unset.
+	fromDay, toDay int
+
+	// minAmount and maxAmount bound the amount; nil leaves a side open.
+	minAmount, maxAmount *float64
+
+	// categoryRegex matches the displayed category name. SQLite has no
+	// regexp support, so it is applied in memory by apply.
+	categoryRegex *regexp.Regexp
+}
+
+// where returns the SQL WHERE clause (including the keyword, or empty when
+// there is nothing to filter) and its bound parameters.
+func (f expenseFilter) where() (string, []any) {
+	var conditions []string
+	var args []any
+
+	if len(f.categories) > 0 {
+		var named []string
+		matchEmpty := false
+		for _, cat := range f.categories {
+			if cat == "" {
+				matchEmpty = true
+				continue
+			}
+			named = append(named, cat)
+			args = append(args, cat)
+		}
+
+		var alternatives []string
+		if len(named) > 0 {
+			alternatives = append(alternatives, "category IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(named)), ", ")+")")
+		}
+		if matchEmpty {
+			alternatives = append(alternatives, "category IS NULL OR category = ''")
+		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+
+	if f.day > 0 {
+		conditions = append(conditions, "day = ?")
+		args = append(args, f.day)
+	}
+
+	if f.fromDay > 0 && f.toDay > 0 {
+		if f.fromDay <= f.toDay {
+			conditions = append(conditions, "day BETWEEN ? AND ?")
+		} else {
+			conditions = append(conditions, "(day >= ? OR day <= ?)")
+		}
+		args = append(args, f.fromDay, f.toDay)
+	}
+
+	if f.minAmount != nil {
+		conditions = append(conditions, "amount >= ?")
+		args = append(args, *f.minAmount)
+	}
+	if f.maxAmount != nil {
+		conditions = append(conditions, "amount <= ?")
+		args = append(args, *f.maxAmount)
+	}
+
+	if f.search != "" {
+		condition, arg := searchCondition(f.search)
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// apply drops fetched expenses that fail the filters which cannot be
+// expressed in SQL.
+func (f expenseFilter) apply(expenses []Expense) []Expense {
+	if f.categoryRegex == nil {
+		return expenses
+	}
+	var matched []Expense
+	for _, exp := range expenses {
+		if f.categoryRegex.MatchString(categoryLabel(exp.Category)) {
+			matched = append(matched, exp)
+		}
+	}
+	return matched
+}
+
+// addFilterFlags registers the flags that narrow which expenses a command
+// works on, shared by ls and export so both filter identically.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("category", "c", nil, "Only include expenses in this category (repeatable; \"\" matches uncategorized)")
+	cmd.Flags().Bool("uncategorized", false, "Only include expenses without a category")
+	cmd.Flags().String("category-regex", "", "Only include expenses whose category matches this regular expression")
+	cmd.Flags().StringP("search", "s", "", "Only include expenses whose title contains this text, ignoring case")
+	cmd.Flags().Float64("min", 0, "Only include expenses of at least this amount")
+	cmd.Flags().Float64("max", 0, "Only include expenses of at most this amount")
+	cmd.Flags().Int("from-day", 0, "Only include expenses due on or after this day of the month")
+	cmd.Flags().Int("to-day", 0, "Only include expenses due on or before this day of the month (wraps past month end if before --from-day)")
+
+	cmd.MarkFlagsMutuallyExclusive("uncategorized", "category")
+}
+
+// readFilterFlags builds an expenseFilter from the flags registered by
+// addFilterFlags, stopping on invalid values before anything is queried.
+func readFilterFlags(cmd *cobra.Command) expenseFilter {
+	var f expenseFilter
+	f.categories, _ = cmd.Flags().GetStringArray("category")
+	if uncategorized, _ := cmd.Flags().GetBool("uncategorized"); uncategorized {
+		f.categories = []string{""}
+	}
+	f.search, _ = cmd.Flags().GetString("search")
+
+	if pattern, _ := cmd.Flags().GetString("category-regex"); pattern != "" {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Fatalf("Error: Invalid category regex '%s': %v", pattern, err)
+		}
+		f.categoryRegex = regex
+	}
+
+	if cmd.Flags().Changed("min") {
+		minAmount, _ := cmd.Flags().GetFloat64("min")
+		f.minAmount = &minAmount
+	}
+	if cmd.Flags().Changed("max") {
+		maxAmount, _ := cmd.Flags().GetFloat64("max")
+		f.maxAmount = &maxAmount
+	}
+	if f.minAmount != nil && f.maxAmount != nil && *f.minAmount > *f.maxAmount {
+		log.Fatalf("Error: --min (%g) must not be greater than --max (%g).", *f.minAmount, *f.maxAmount)
+	}
+
+	if cmd.Flags().Changed("from-day") || cmd.Flags().Changed("to-day") {
+		f.fromDay, _ = cmd.Flags().GetInt("from-day")
+		f.toDay, _ = cmd.Flags().GetInt("to-day")
+		if !cmd.Flags().Changed("from-day") {
+			f.fromDay = 1
+		}
+		if !cmd.Flags().Changed("to-day") {
+			f.toDay = 31
+		}
+		for _, day := range []int{f.fromDay, f.toDay} {
+			if day < 1 || day > 31 {
+				log.Fatalf("Error: Invalid day '%d'. Please provide a day between 1 and 31.", day)
+			}
+		}
+	}
+	return f
+}
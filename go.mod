@@ -3,6 +3,9 @@ module github.com/elliot40404/monke
 go 1.24.1
 
 require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/atotto/clipboard v0.1.4
+	github.com/mattn/go-runewidth v0.0.9
 	github.com/mattn/go-sqlite3 v1.14.28
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/spf13/cobra v1.9.1
@@ -10,6 +13,5 @@ require (
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 )
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// categoryParent returns the top-level part of a hierarchical category such
+// as "Food:Dining", or the category itself when no separator is configured.
+func categoryParent(cat string) string {
+	if cfg.CategorySeparator == "" {
+		return cat
+	}
+	parent, _, _ := strings.Cut(cat, cfg.CategorySeparator)
+	return parent
+}
+
+// categoryRollup holds category totals summed into their top-level parents.
+type categoryRollup struct {
+	parents      []string
+	parentTotals map[string]float64
+	children     map[string][]string
+}
+
+// rollupCategories groups sub-categories under their parents, ordering both
+// as sortCategories orders flat categories; a parent ranks in the category
+// order where its first listed sub-category does. It reports false when no
+// category is hierarchical, so flat categories render unchanged.
+func rollupCategories(categoryTotalsMap map[string]float64, opts tableOptions) (categoryRollup, bool) {
+	rollup := categoryRollup{
+		parentTotals: make(map[string]float64),
+		children:     make(map[string][]string),
+	}
+	hierarchical := false
+	for cat, amount := range categoryTotalsMap {
+		parent := categoryParent(cat)
+		rollup.parentTotals[parent] += amount
+		if parent != cat {
+			rollup.children[parent] = append(rollup.children[parent], cat)
+			hierarchical = true
+		}
+	}
+	if !hierarchical {
+		return rollup, false
+	}
+
+	for parent := range rollup.parentTotals {
+		rollup.parents = append(rollup.parents, parent)
+	}
+	parentOpts := opts
+	parentOpts.categoryOrder = nil
+	for _, cat := range opts.categoryOrder {
+		if parent := categoryParent(cat); !slices.Contains(parentOpts.categoryOrder, parent) {
+			parentOpts.categoryOrder = append(parentOpts.categoryOrder, parent)
+		}
+	}
+	sortCategories(rollup.parents, rollup.parentTotals, parentOpts)
+	for _, children := range rollup.children {
+		sortCategories(children, categoryTotalsMap, opts)
+	}
+	return rollup, true
+}
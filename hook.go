@@ -0,0 +1,40 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// runPostAddHook runs the configured post_add_hook after an expense was
+// added. The expense is passed in MONKE_* environment variables rather
+// than substituted into the command, so titles cannot inject shell code.
+// A failing hook is reported but does not undo the insert.
+func runPostAddHook(exp Expense) {
+	if cfg.PostAddHook == "" {
+		return
+	}
+
+	var hook *exec.Cmd
+	if runtime.GOOS == "windows" {
+		hook = exec.Command("cmd", "/C", cfg.PostAddHook)
+	} else {
+		hook = exec.Command("sh", "-c", cfg.PostAddHook)
+	}
+	hook.Env = append(os.Environ(),
+		"MONKE_ID="+strconv.Itoa(exp.ID),
+		"MONKE_TITLE="+exp.Title,
+		"MONKE_AMOUNT="+strconv.FormatFloat(exp.Amount, 'f', -1, 64),
+		"MONKE_DAY="+strconv.Itoa(exp.Day),
+		"MONKE_CATEGORY="+exp.Category,
+		"MONKE_TIME="+exp.Time,
+	)
+	hook.Stdout = os.Stdout
+	hook.Stderr = os.Stderr
+
+	if err := hook.Run(); err != nil {
+		log.Printf("Warning: post_add_hook failed for expense %d: %v", exp.ID, err)
+	}
+}
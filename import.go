@@ -0,0 +1,421 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// importFields are the expense fields a CSV column can be mapped onto.
+// "date" is an alternative to "day" for bank exports with full dates.
+var importFields = []string{"title", "amount", "day", "date", "category", "time"}
+
+// importRowError records a CSV line that could not be turned into an expense.
+type importRowError struct {
+	line int
+	err  error
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import expenses from a CSV file, JSON array or SQL dump",
+	Long: `Import expenses from a CSV file with a header row.
+
+By default columns are matched to fields by name (title, amount, day,
+date, category, time). Use --map to map a bank's own column names, e.g.
+  --map "date=Transaction Date,amount=Debit,title=Description"
+
+Amounts may carry currency symbols and thousands separators, e.g.
+"$1,234.50". Use --decimal-comma for exports such as "1.234,50 EUR";
+amounts whose separators do not fit the chosen style are rejected.
+
+All rows are validated before anything is written. If any row fails,
+the errors are reported and nothing is imported, unless
+--continue-on-error is given, in which case failing rows are reported and
+skipped. Use --dry-run to preview the import.
+
+With --sql the file is a dump written by 'export --format sql'. It is
+replayed into a scratch in-memory database, so only its expenses are
+imported; ids are reassigned.
+
+With --from-json the file is an array of expense objects, as written by
+'export --format json'. Errors are reported by array index and ids are
+reassigned.
+
+Categories named in the category_aliases config, or in --category-aliases,
+are rewritten, e.g. --category-aliases "AMZN=Shopping", ignoring case.
+The summary reports how many expenses each alias mapped.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		mapping, _ := cmd.Flags().GetString("map")
+		dateFormat, _ := cmd.Flags().GetString("date-format")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		sqlDump, _ := cmd.Flags().GetBool("sql")
+		fromJSON, _ := cmd.Flags().GetBool("from-json")
+		continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+		aliasFlag, _ := cmd.Flags().GetString("category-aliases")
+		if !dryRun {
+			requireWritable(cmd)
+		}
+
+		fieldColumns, err := parseImportMap(mapping)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		extraAliases, err := parseCategoryAliases(aliasFlag)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalf("Error opening import file: %v", err)
+		}
+		defer file.Close()
+
+		// Aliases are resolved as each row is read, before it is
+		// validated.
+		rewriter := newAliasRewriter(newCategoryAliases(cfg.CategoryAliases, extraAliases))
+		var expenses []Expense
+		var rowErrors []importRowError
+		position := "line"
+		switch {
+		case sqlDump:
+			expenses, rowErrors = readImportSQL(file, rewriter)
+			position = "row"
+		case fromJSON:
+			expenses, rowErrors = readImportJSON(file, rewriter)
+			position = "index"
+		default:
+			expenses, rowErrors = readImportCSV(file, fieldColumns, dateFormat, rewriter)
+		}
+		if len(rowErrors) > 0 {
+			for _, rowErr := range rowErrors {
+				fmt.Fprintf(os.Stderr, "%s %d: %v\n", position, rowErr.line, rowErr.err)
+			}
+			if !continueOnError {
+				log.Fatalf("Error: %d row(s) could not be parsed; nothing was imported.", len(rowErrors))
+			}
+		}
+
+		if dryRun {
+			printImportPreview(expenses, len(rowErrors))
+			printAliasMappings(rewriter.mapped)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Fatalf("Error starting transaction: %v", err)
+		}
+		defer tx.Rollback()
+
+		for _, exp := range expenses {
+			if _, err := insertExpense(tx, exp); err != nil {
+				log.Fatalf("Error inserting expense '%s': %v", exp.Title, err)
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("Error committing import: %v", err)
+		}
+
+		if continueOnError {
+			fmt.Printf("Imported %d expenses, skipped %d.\n", len(expenses), len(rowErrors))
+		} else {
+			fmt.Printf("Imported %d expenses.\n", len(expenses))
+		}
+		printAliasMappings(rewriter.mapped)
+	},
+}
+
+// printImportPreview reports what an import would add, broken down by category.
+// skipped rows are counted when --continue-on-error is in effect.
+func printImportPreview(expenses []Expense, skipped int) {
+	counts := make(map[string]int)
+	totals := make(map[string]float64)
+	for _, exp := range expenses {
+		label := categoryLabel(exp.Category)
+		counts[label]++
+		totals[label] += exp.Amount
+	}
+
+	var categories []string
+	for cat := range counts {
+		categories = append(categories, cat)
+	}
+	sort.Strings(categories)
+
+	fmt.Printf("Dry run: %d expenses would be imported", len(expenses))
+	if skipped > 0 {
+		fmt.Printf(", %d skipped", skipped)
+	}
+	fmt.Println(".")
+	for _, cat := range categories {
+		fmt.Printf("  - %s: %d (%s)\n", cat, counts[cat], formatAmount(totals[cat]))
+	}
+}
+
+// parseImportMap parses a "field=Column,field=Column" mapping into a map of
+// field name to CSV header. Fields that are not mapped keep their own name.
+func parseImportMap(mapping string) (map[string]string, error) {
+	fieldColumns := make(map[string]string)
+	for _, field := range importFields {
+		fieldColumns[field] = field
+	}
+	if mapping == "" {
+		return fieldColumns, nil
+	}
+
+	for _, pair := range strings.Split(mapping, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid mapping '%s', expected field=Column", pair)
+		}
+		if _, known := fieldColumns[field]; !known {
+			return nil, fmt.Errorf("unknown field '%s' in mapping, valid fields: %s", field, strings.Join(importFields, ", "))
+		}
+		fieldColumns[field] = column
+	}
+	return fieldColumns, nil
+}
+
+// readImportCSV parses every data row of a CSV file into expenses, collecting
+// a row error for each line that fails instead of stopping at the first one.
+func readImportCSV(r io.Reader, fieldColumns map[string]string, dateFormat string, rewriter *aliasRewriter) ([]Expense, []importRowError) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		log.Fatalf("Error reading CSV header: %v", err)
+	}
+
+	columnIndex := make(map[string]int)
+	for i, name := range header {
+		columnIndex[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	fieldIndex := make(map[string]int)
+	for field, column := range fieldColumns {
+		if i, ok := columnIndex[strings.ToLower(column)]; ok {
+			fieldIndex[field] = i
+		}
+	}
+
+	for _, field := range []string{"title", "amount"} {
+		if _, ok := fieldIndex[field]; !ok {
+			log.Fatalf("Error: CSV has no column for '%s' (looked for '%s').", field, fieldColumns[field])
+		}
+	}
+	_, hasDay := fieldIndex["day"]
+	_, hasDate := fieldIndex["date"]
+	if !hasDay && !hasDate {
+		log.Fatalf("Error: CSV has no column for 'day' or 'date'.")
+	}
+
+	var expenses []Expense
+	var rowErrors []importRowError
+	clamped := 0
+	line := 1
+	for {
+		record, err := reader.Read()
+		line++
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, importRowError{line, err})
+			continue
+		}
+
+		value := func(field string) string {
+			i, ok := fieldIndex[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		exp, moved, err := parseImportRecord(value, hasDay, dateFormat)
+		if err == nil {
+			err = rewriter.validate(&exp)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, importRowError{line, err})
+			continue
+		}
+		if moved {
+			clamped++
+		}
+		expenses = append(expenses, exp)
+	}
+	if clamped > 0 {
+		log.Printf("Warning: %d row(s) dated after the 28th were moved to the 28th.", clamped)
+	}
+	return expenses, rowErrors
+}
+
+// readImportSQL replays an SQL dump into an in-memory database and reads
+// the expenses back from it, validating each one.
+func readImportSQL(r io.Reader, rewriter *aliasRewriter) ([]Expense, []importRowError) {
+	dump, err := io.ReadAll(r)
+	if err != nil {
+		log.Fatalf("Error reading SQL dump: %v", err)
+	}
+
+	scratch, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		log.Fatalf("Error opening scratch database: %v", err)
+	}
+	defer scratch.Close()
+	// Every connection to :memory: is a separate database.
+	scratch.SetMaxOpenConns(1)
+
+	if err := replayDump(scratch, string(dump)); err != nil {
+		log.Fatalf("Error replaying SQL dump: %v", err)
+	}
+
+	return readMergeExpenses(scratch, rewriter)
+}
+
+// readImportJSON decodes an array of expense objects, validating each
+// element on its own so that errors can be reported by array index.
+func readImportJSON(r io.Reader, rewriter *aliasRewriter) ([]Expense, []importRowError) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&elements); err != nil {
+		log.Fatalf("Error decoding JSON array: %v", err)
+	}
+
+	var expenses []Expense
+	var rowErrors []importRowError
+	for i, element := range elements {
+		exp, err := decodeExpenseJSON(element)
+		if err != nil {
+			rowErrors = append(rowErrors, importRowError{i, err})
+			continue
+		}
+		if err := rewriter.validate(&exp); err != nil {
+			rowErrors = append(rowErrors, importRowError{i, err})
+			continue
+		}
+		expenses = append(expenses, exp)
+	}
+	return expenses, rowErrors
+}
+
+// parseImportRecord builds an expense from one CSV row, leaving validation
+// to the caller. Expenses fall on days 1-28, so a date later in the month
+// is clamped to the 28th and reported as clamped.
+func parseImportRecord(value func(field string) string, hasDay bool, dateFormat string) (exp Expense, clamped bool, err error) {
+	exp = Expense{
+		Title:    value("title"),
+		Category: value("category"),
+		Time:     value("time"),
+	}
+
+	amount, err := parseAmount(value("amount"))
+	if err != nil {
+		return exp, false, err
+	}
+	exp.Amount = amount
+
+	if hasDay {
+		day, err := strconv.Atoi(value("day"))
+		if err != nil {
+			return exp, false, fmt.Errorf("invalid day '%s'", value("day"))
+		}
+		exp.Day = day
+	} else {
+		date, err := time.Parse(dateFormat, value("date"))
+		if err != nil {
+			return exp, false, fmt.Errorf("invalid date '%s', expected format %s", value("date"), dateFormat)
+		}
+		exp.Day = min(date.Day(), 28)
+		clamped = date.Day() > 28
+	}
+	return exp, clamped, nil
+}
+
+// commaGroupedNumber matches a number whose commas separate groups of
+// exactly three digits, e.g. "1,234,567.50".
+var commaGroupedNumber = regexp.MustCompile(`^[+-]?\d{1,3}(,\d{3})+(\.\d+)?$`)
+
+// parseAmount parses an amount as written in bank exports, ignoring currency
+// symbols, e.g. "$1,234.50" or "-12.00 EUR". Thousands separators are only
+// dropped between groups of three digits; with --decimal-comma the roles of
+// ',' and '.' swap as in add. Anything else, such as "12,50" without
+// --decimal-comma, is rejected rather than guessed.
+func parseAmount(raw string) (float64, error) {
+	// Keep only digits, separators and sign; symbols and spaces are
+	// dropped.
+	var cleaned strings.Builder
+	for _, r := range raw {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' || r == '-' || r == '+' {
+			cleaned.WriteRune(r)
+		}
+	}
+	number := cleaned.String()
+	if decimalComma {
+		converted, err := fromDecimalComma(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount '%s': %w", raw, err)
+		}
+		number = converted
+	} else {
+		converted, err := fromThousandsComma(number)
+		if err != nil {
+			return 0, fmt.Errorf("ambiguous amount '%s': %w", raw, err)
+		}
+		number = converted
+	}
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount '%s'", raw)
+	}
+	return amount, nil
+}
+
+// fromThousandsComma drops the commas of a number that uses them as
+// thousands separators, refusing any other comma as ambiguous.
+func fromThousandsComma(number string) (string, error) {
+	if !strings.Contains(number, ",") {
+		return number, nil
+	}
+	if !commaGroupedNumber.MatchString(number) {
+		return "", errors.New("',' is only read as a thousands separator; use --decimal-comma for amounts such as 12,50")
+	}
+	return strings.ReplaceAll(number, ",", ""), nil
+}
+
+func init() {
+	importCmd.Flags().String("map", "", "Map CSV columns to fields, e.g. \"date=Transaction Date,amount=Debit\"")
+	importCmd.Flags().Bool("sql", false, "Read an SQL dump written by 'export --format sql' instead of CSV")
+	importCmd.Flags().Bool("from-json", false, "Read a JSON array of expenses, as written by 'export --format json'")
+	importCmd.Flags().Bool("continue-on-error", false, "Skip rows that fail to parse and import the rest")
+	importCmd.Flags().Bool("dry-run", false, "Validate the file and show what would be imported without writing anything")
+	importCmd.Flags().String("category-aliases", "", "Rewrite categories, e.g. \"AMZN=Shopping,UBER=Transport\"; adds to the category_aliases config")
+	importCmd.Flags().BoolVar(&decimalComma, "decimal-comma", false, "Read amounts with a comma as the decimal separator, e.g. 1.234,50")
+	importCmd.Flags().String("date-format", "2006-01-02", "Go layout used to parse the date column")
+
+	importCmd.MarkFlagsMutuallyExclusive("sql", "map")
+	importCmd.MarkFlagsMutuallyExclusive("sql", "date-format")
+	importCmd.MarkFlagsMutuallyExclusive("sql", "from-json")
+	importCmd.MarkFlagsMutuallyExclusive("from-json", "map")
+	importCmd.MarkFlagsMutuallyExclusive("from-json", "date-format")
+	importCmd.MarkFlagsMutuallyExclusive("sql", "decimal-comma")
+	importCmd.MarkFlagsMutuallyExclusive("from-json", "decimal-comma")
+}
@@ -1,15 +1,18 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"math"
 	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/mattn/go-runewidth"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -22,9 +25,25 @@ const (
 	colorFutureNear = "\033[38;5;198m" // Future (1-3 days away) (Hot Pink)
 	colorFutureMid  = "\033[38;5;208m" // Future (4-5 days away) (Orange 1)
 
-	statusIndicator = "●"
+	colorOverBudget = "\033[38;5;196m" // Over budget (Red 1)
+	colorOther      = "\033[38;5;245m" // Collapsed small categories (Gray 54)
+
+	bold = "\033[1m"
+
+	highlight = "\033[1;7m" // Titles matching --highlight (bold, reversed)
+
+	colorDebit  = "\033[38;5;160m" // Ledger debits (Red 3)
+	colorCredit = "\033[38;5;34m"  // Ledger credits (Green 3)
 
-	lineCharacter = "■"
+	otherCategory = "Other"
+)
+
+// statusIndicator and lineCharacter draw the status column and category
+// bar. loadConfig replaces them from the config, --status-glyph, --bar-char
+// and --ascii.
+var (
+	statusIndicator = "●"
+	lineCharacter   = "■"
 )
 
 var categoryColors = []string{
@@ -40,160 +59,856 @@ var categoryColors = []string{
 	"\033[38;5;65m",  // Medium Spring Green
 }
 
+// amountGradient runs from green for the smallest amounts to red for the
+// largest, used by the --heatmap Amount column.
+var amountGradient = []string{
+	"\033[38;5;46m",  // Green 1
+	"\033[38;5;82m",  // Chartreuse 2
+	"\033[38;5;118m", // Chartreuse 1
+	"\033[38;5;154m", // Green Yellow
+	"\033[38;5;190m", // Yellow 3
+	"\033[38;5;226m", // Yellow 1
+	"\033[38;5;220m", // Gold 1
+	"\033[38;5;214m", // Orange 1
+	"\033[38;5;208m", // Dark Orange
+	"\033[38;5;202m", // Orange Red 1
+	"\033[38;5;196m", // Red 1
+}
+
+// namedColors maps the color names accepted by --color to 256-color codes.
+var namedColors = map[string]int{
+	"black":   0,
+	"red":     196,
+	"green":   40,
+	"yellow":  226,
+	"blue":    21,
+	"magenta": 201,
+	"cyan":    51,
+	"white":   231,
+	"gray":    245,
+	"grey":    245,
+	"orange":  208,
+	"pink":    198,
+	"purple":  141,
+}
+
+var noColor bool
+
+// compactAmounts shortens large amounts in formatAmount; see --compact.
+var compactAmounts bool
+
+// colorize wraps text in the given color unless colors are disabled.
+func colorize(color, text string) string {
+	if noColor {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// amountColor maps an amount onto amountGradient relative to maxAmount.
+func amountColor(amount, maxAmount float64) string {
+	if maxAmount <= 0 {
+		return amountGradient[0]
+	}
+	ratio := math.Max(0, math.Min(1, amount/maxAmount))
+	return amountGradient[int(math.Round(ratio*float64(len(amountGradient)-1)))]
+}
+
 var lsCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "List all expenses",
-	Run: func(_ *cobra.Command, _ []string) {
-		rows, err := db.Query("SELECT id, title, amount, day, category FROM expenses ORDER BY day ASC")
+	Run: func(cmd *cobra.Command, _ []string) {
+		if jsonSchema, _ := cmd.Flags().GetBool("json-schema"); jsonSchema {
+			printJSON(listJSONSchema())
+			return
+		}
+
+		recent, _ := cmd.Flags().GetInt("recent")
+		baseFilter := readFilterFlags(cmd)
+		colorFlags, _ := cmd.Flags().GetStringArray("color")
+		colorOverrides, err := parseColorOverrides(colorFlags)
 		if err != nil {
-			if strings.Contains(err.Error(), "no such column: day") {
-				log.Fatalf("Error: Database schema mismatch. Clear the database with 'monke clear'.")
+			log.Fatalf("Error: %v", err)
+		}
+		var categoryOrder []string
+		if onlyCategories, _ := cmd.Flags().GetStringSlice("only-categories"); len(onlyCategories) > 0 {
+			baseFilter.categories = onlyCategories
+			for _, cat := range onlyCategories {
+				categoryOrder = append(categoryOrder, categoryLabel(cat))
+			}
+		}
+		today, _ := cmd.Flags().GetBool("today")
+		payPeriod, _ := cmd.Flags().GetBool("pay-period")
+		if payPeriod && cfg.Payday == 0 {
+			log.Fatal("Error: No payday set. Set it with 'monke config set payday <day>'.")
+		}
+		noHeader, _ := cmd.Flags().GetBool("no-header")
+		headerStyle, _ := cmd.Flags().GetString("header-style")
+		categoryCase, _ := cmd.Flags().GetString("category-case")
+		barLabels, _ := cmd.Flags().GetBool("bar-labels")
+		sortStable, _ := cmd.Flags().GetBool("sort-stable")
+		if !slices.Contains(headerStyles, headerStyle) {
+			log.Fatalf("Error: Invalid header style '%s'. Valid styles: %s.", headerStyle, strings.Join(headerStyles, ", "))
+		}
+		if !slices.Contains(headerStyles, categoryCase) {
+			log.Fatalf("Error: Invalid category case '%s'. Valid cases: %s.", categoryCase, strings.Join(headerStyles, ", "))
+		}
+		heatmap, _ := cmd.Flags().GetBool("heatmap")
+		ledger, _ := cmd.Flags().GetBool("ledger")
+		sumRow, _ := cmd.Flags().GetBool("sum-row")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		maxRows, _ := cmd.Flags().GetInt("max-rows")
+		relative, _ := cmd.Flags().GetBool("relative")
+		percentOfIncome, _ := cmd.Flags().GetBool("percent-of-income")
+		income := 0.0
+		if percentOfIncome {
+			if cfg.Income <= 0 {
+				log.Fatal("Error: No income set. Set your monthly income with 'monke config set income <amount>'.")
+			}
+			income = cfg.Income
+		}
+		secondaryCurrency, secondaryRate := cfg.SecondaryCurrency, cfg.SecondaryRate
+		if cmd.Flags().Changed("secondary-currency") {
+			secondaryCurrency, _ = cmd.Flags().GetString("secondary-currency")
+			if err := validateCurrency(secondaryCurrency); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+		if cmd.Flags().Changed("rate") {
+			secondaryRate, _ = cmd.Flags().GetFloat64("rate")
+			if secondaryRate < 0 {
+				log.Fatalf("Error: Invalid rate '%g'. Please provide a non-negative number.", secondaryRate)
 			}
-			log.Fatalf("Error querying expenses: %v", err)
 		}
-		defer rows.Close()
+		jsonOut, _ := cmd.Flags().GetBool("json")
+		summaryFormat, _ := cmd.Flags().GetString("summary-format")
+		if summaryFormat != "" && !slices.Contains(summaryFormats, summaryFormat) {
+			log.Fatalf("Error: Invalid summary format '%s'. Valid formats: %s.", summaryFormat, strings.Join(summaryFormats, ", "))
+		}
+		idsOnly, _ := cmd.Flags().GetBool("ids-only")
+		print0, _ := cmd.Flags().GetBool("print0")
+		if print0 && !idsOnly {
+			log.Fatal("Error: --print0 requires --ids-only.")
+		}
+		totalBy, _ := cmd.Flags().GetString("total-by")
+		minPercent, _ := cmd.Flags().GetFloat64("min-percent")
+		if minPercent < 0 || minPercent > 100 {
+			log.Fatalf("Error: Invalid min percent '%g'. Please provide a value between 0 and 100.", minPercent)
+		}
+		barOnly, _ := cmd.Flags().GetBool("bar-only")
+		tree, _ := cmd.Flags().GetBool("tree")
+		groupSeparator, _ := cmd.Flags().GetString("group-separator")
+		if groupSeparator != "" && !slices.Contains(groupSeparators, groupSeparator) {
+			log.Fatalf("Error: Invalid group separator '%s'. Valid separators: %s.", groupSeparator, strings.Join(groupSeparators, ", "))
+		}
+		if groupSeparator != "" && !tree {
+			log.Fatal("Error: --group-separator requires --tree.")
+		}
+		amountAbs, _ := cmd.Flags().GetBool("amount-abs")
+		watchMode, _ := cmd.Flags().GetBool("watch")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		totalLast, _ := cmd.Flags().GetBool("total-last")
+		noCategoryBar, _ := cmd.Flags().GetBool("no-category-bar")
+		noCategoryTotals, _ := cmd.Flags().GetBool("no-category-totals")
+		noGrandTotal, _ := cmd.Flags().GetBool("no-grand-total")
+		maxTitleWidth, _ := cmd.Flags().GetInt("max-title-width")
+		highlightTerm, _ := cmd.Flags().GetString("highlight")
+		if maxTitleWidth < 0 {
+			log.Fatalf("Error: Invalid max title width '%d'. Please provide a positive number.", maxTitleWidth)
+		}
+		minWidth, _ := cmd.Flags().GetInt("min-width")
+		maxWidth, _ := cmd.Flags().GetInt("max-width")
+		if minWidth < 0 || maxWidth < 0 {
+			log.Fatal("Error: Invalid bar width clamp. Please provide positive numbers for --min-width and --max-width.")
+		}
+		if minWidth > 0 && maxWidth > 0 && minWidth > maxWidth {
+			log.Fatalf("Error: --min-width (%d) must not be greater than --max-width (%d).", minWidth, maxWidth)
+		}
+		if maxRows < 0 {
+			log.Fatalf("Error: Invalid max rows '%d'. Please provide a positive number.", maxRows)
+		}
+		if recent < 0 {
+			log.Fatalf("Error: Invalid recent count '%d'. Please provide a positive number.", recent)
+		}
+
+		render := func() {
+			filter := baseFilter
+			if today {
+				filter.day = currentTime().Day()
+			}
+			var periodStart time.Time
+			if payPeriod {
+				now := currentTime()
+				periodStart = lastPayday(now, cfg.Payday)
+				filter.fromDay, filter.toDay = periodStart.Day(), now.Day()
+			}
+			if totalBy != "" {
+				totals := queryGroupTotals(totalBy, filter)
+				if jsonOut {
+					printJSON(totals)
+				} else {
+					renderGroupTotals(totalBy, totals)
+				}
+				return
+			}
+
+			where, args := filter.where()
+
+			query := selectExpensesSQL + where + orderByDueSQL
+			if sortStable {
+				query += stableTiebreakSQL
+			}
+			// The category regex is matched in memory, so with one the
+			// limit is applied after filtering rather than in SQL.
+			if recent > 0 {
+				query = selectExpensesSQL + where + " ORDER BY id DESC"
+				if filter.categoryRegex == nil {
+					query += " LIMIT ?"
+					args = append(args, recent)
+				}
+			}
 
-		var expenses []Expense
-		totalAmount := 0.0
-		categoryTotalsMap := make(map[string]float64)
-		uniqueCategories := make(map[string]struct{})
-		totalLineWidth := 80 // Default width for the colored line
+			expenses := filter.apply(queryExpenses(query, args...))
+			if recent > 0 && len(expenses) > recent {
+				expenses = expenses[:recent]
+			}
+			var absTotals map[string]float64
+			if amountAbs {
+				sort.SliceStable(expenses, func(i, j int) bool {
+					return math.Abs(expenses[i].Amount) > math.Abs(expenses[j].Amount)
+				})
+				absTotals = make(map[string]float64)
+				for _, exp := range expenses {
+					absTotals[categoryLabel(exp.Category)] += math.Abs(exp.Amount)
+				}
+			}
+			if len(categoryOrder) > 0 {
+				sort.SliceStable(expenses, func(i, j int) bool {
+					return categoryRank(categoryOrder, categoryLabel(expenses[i].Category)) < categoryRank(categoryOrder, categoryLabel(expenses[j].Category))
+				})
+			}
+			totalAmount := 0.0
+			categoryTotalsMap := make(map[string]float64)
+			uniqueCategories := make(map[string]struct{})
+			totalLineWidth := clampWidth(cfg.Width, minWidth, maxWidth)
 
-		for rows.Next() {
-			var exp Expense
-			var category sql.NullString
+			for _, exp := range expenses {
+				displayCategory := categoryLabel(exp.Category)
+				categoryTotalsMap[displayCategory] += exp.Amount
+				uniqueCategories[displayCategory] = struct{}{}
+				totalAmount += exp.Amount
+			}
 
-			err := rows.Scan(&exp.ID, &exp.Title, &exp.Amount, &exp.Day, &category)
-			if err != nil {
-				log.Printf("Error scanning row: %v", err)
-				continue
+			if idsOnly {
+				terminator := "\n"
+				if print0 {
+					terminator = "\x00"
+				}
+				for _, exp := range expenses {
+					fmt.Printf("%d%s", exp.ID, terminator)
+				}
+				return
 			}
 
-			displayCategory := "Uncategorized"
-			if category.Valid && category.String != "" {
-				exp.Category = category.String
-				displayCategory = exp.Category
-			} else {
-				exp.Category = ""
+			if jsonOut {
+				printJSON(newListJSON(expenses, totalAmount, categoryTotalsMap))
+				return
 			}
-			categoryTotalsMap[displayCategory] += exp.Amount
-			uniqueCategories[displayCategory] = struct{}{}
 
-			expenses = append(expenses, exp)
-			totalAmount += exp.Amount
-		}
+			if summaryFormat != "" {
+				summary := newSummaryJSON(len(expenses), totalAmount, categoryTotalsMap)
+				if len(categoryOrder) > 0 {
+					sort.SliceStable(summary.Categories, func(i, j int) bool {
+						return categoryRank(categoryOrder, summary.Categories[i].Name) < categoryRank(categoryOrder, summary.Categories[j].Name)
+					})
+				}
+				printSummaryFormat(summaryFormat, summary)
+				return
+			}
+
+			if len(expenses) == 0 {
+				printNoExpenses()
+				return
+			}
+
+			now := currentTime()
+			currentDay := now.Day()
+			currentMonthName := monthName(now.Month())
+
+			categoryColorMap := assignCategoryColors(categoryTotalsMap)
+			for cat, color := range colorOverrides {
+				categoryColorMap[cat] = color
+			}
+
+			opts := tableOptions{
+				noHeader:          noHeader,
+				headerStyle:       headerStyle,
+				heatmap:           heatmap,
+				ledger:            ledger,
+				sumRow:            sumRow,
+				verbose:           verbose,
+				maxRows:           maxRows,
+				totalLast:         totalLast,
+				noCategoryBar:     noCategoryBar,
+				noCategoryTotals:  noCategoryTotals,
+				noGrandTotal:      noGrandTotal,
+				maxTitleWidth:     maxTitleWidth,
+				highlight:         strings.ToLower(highlightTerm),
+				minPercent:        minPercent,
+				relative:          relative,
+				income:            income,
+				secondaryCurrency: secondaryCurrency,
+				secondaryRate:     secondaryRate,
+				budgets:           loadBudgets(),
+				categoryOrder:     categoryOrder,
+				absTotals:         absTotals,
+				categoryCase:      categoryCase,
+				barLabels:         barLabels,
+				sortStable:        sortStable,
+			}
+
+			switch {
+			case barOnly:
+				renderSummary(totalAmount, categoryTotalsMap, categoryColorMap, totalLineWidth, opts)
+			case tree:
+				renderExpenseTree(expenses, currentMonthName, categoryColorMap, groupSeparator, totalLineWidth)
+				fmt.Println()
+				renderSummary(totalAmount, categoryTotalsMap, categoryColorMap, totalLineWidth, opts)
+			default:
+				renderExpenseTable(expenses, totalAmount, categoryTotalsMap, currentDay, currentMonthName, categoryColorMap, totalLineWidth, opts)
+			}
+
+			if payPeriod {
+				fmt.Printf("\nPay period since %02d %s: %s spent%s\n", periodStart.Day(), monthName(periodStart.Month()), formatAmount(totalAmount), incomeShare(totalAmount, cfg.Income))
+			}
 
-		if err = rows.Err(); err != nil {
-			log.Fatalf("Error iterating rows: %v", err)
+			if recent > 0 {
+				fmt.Printf("\nNote: showing the %d most recently added expenses; totals reflect these rows only.\n", len(expenses))
+			}
 		}
 
-		if len(expenses) == 0 {
-			fmt.Println("No expenses found.")
+		if watchMode {
+			if interval <= 0 {
+				log.Fatalf("Error: Invalid interval '%s'. Please provide a positive duration.", interval)
+			}
+			watch(interval, render)
 			return
 		}
+		render()
+	},
+}
 
-		now := time.Now()
-		currentDay := now.Day()
-		currentMonthName := now.Format("January")
+func init() {
+	lsCmd.Flags().IntP("recent", "r", 0, "Show only the N most recently added expenses")
+	addFilterFlags(lsCmd)
+	lsCmd.Flags().Bool("today", false, "Only show expenses due today")
+	lsCmd.Flags().Bool("pay-period", false, "Only show expenses due since the last payday (see the payday config key)")
+	lsCmd.Flags().Bool("no-header", false, "Hide the table header for a more compact display")
+	lsCmd.Flags().String("header-style", "upper", "Table header case: upper, title, lower or none")
+	lsCmd.Flags().Bool("sort-stable", false, "Break ties in the listing by id and in the category summary by name, so output is identical across runs")
+	lsCmd.Flags().Bool("bar-labels", false, "Write each category's name and percentage into its segment of the category bar when it fits")
+	lsCmd.Flags().String("category-case", "none", "Show category names in upper, title or lower case; none shows them as stored")
+	lsCmd.Flags().Bool("relative", false, "Show dates relative to today, e.g. \"in 3 days\"")
+	lsCmd.Flags().Bool("percent-of-income", false, "Also show amounts as a percentage of the configured monthly income")
+	lsCmd.Flags().String("secondary-currency", "", "Symbol of a second currency to show converted amounts in")
+	lsCmd.Flags().Float64("rate", 0, "Conversion rate from the primary to the secondary currency (0 disables)")
+	lsCmd.Flags().Bool("heatmap", false, "Color amounts from green (small) to red (large)")
+	lsCmd.Flags().Bool("ledger", false, "Show expenses as negative debits in red and refunds as positive credits in green")
+	lsCmd.Flags().Bool("json", false, "Print expenses and totals as JSON")
+	lsCmd.Flags().Bool("json-schema", false, "Print the JSON Schema of the --json output and exit")
+	lsCmd.Flags().String("summary-format", "", "Print only the totals, as json, csv or table")
+	lsCmd.Flags().Bool("ids-only", false, "Print only the IDs of matching expenses, one per line")
+	lsCmd.Flags().BoolP("print0", "0", false, "With --ids-only, end each ID with a NUL byte instead of a newline, for xargs -0")
+	lsCmd.Flags().Float64("min-percent", 0, "Merge categories below this percentage of the total into \"Other\" in the bar and summary")
+	lsCmd.Flags().String("total-by", "", "Print only totals grouped by category or day")
+	lsCmd.Flags().Bool("watch", false, "Re-render the listing periodically until interrupted")
+	lsCmd.Flags().Duration("interval", 30*time.Second, "Refresh interval for --watch")
+	lsCmd.Flags().Bool("amount-abs", false, "Sort rows and size the category bar by absolute amount; amounts and totals stay signed")
+	lsCmd.Flags().Bool("tree", false, "Show expenses nested under their category path with subtotals instead of a table")
+	lsCmd.Flags().String("group-separator", "", "Separate categories in --tree with a blank line or a colored rule: blank or rule (blank if given without a value)")
+	lsCmd.Flags().Lookup("group-separator").NoOptDefVal = "blank"
+	lsCmd.Flags().Bool("bar-only", false, "Show only the category bar and totals, without the expense table")
+	lsCmd.Flags().BoolVar(&compactAmounts, "compact", false, "Shorten large amounts with k/M/G suffixes (see the compact_threshold config key)")
+	lsCmd.Flags().BoolP("verbose", "v", false, "Also show when each expense was entered")
+	lsCmd.Flags().StringArray("color", nil, "Override a category's color for this run, as category=color (a name or 256-color code; repeatable)")
+	lsCmd.Flags().StringSlice("only-categories", nil, "Only include these comma-separated categories, listed and summarized in the given order")
+	lsCmd.Flags().Int("max-rows", 0, "Show at most N rows and note how many were left out; totals still cover every row (0 for no limit)")
+	lsCmd.Flags().Bool("sum-row", false, "Append a TOTAL row to the table")
+	lsCmd.Flags().Bool("no-category-bar", false, "Hide the colored category bar below the table")
+	lsCmd.Flags().Bool("no-category-totals", false, "Hide the per-category totals")
+	lsCmd.Flags().Bool("no-grand-total", false, "Hide the grand total")
+	lsCmd.Flags().Bool("total-last", false, "Print category totals first and the grand total below a rule")
+	lsCmd.Flags().String("highlight", "", "Highlight titles containing this text without filtering other rows out")
+	lsCmd.Flags().Int("max-title-width", 0, "Truncate titles in the table to this width (0 for no limit)")
 
-		categoryColorMap := make(map[string]string)
-		var categoryNames []string
-		for catName := range categoryTotalsMap {
-			categoryNames = append(categoryNames, catName)
-		}
-		sort.Strings(categoryNames)
+	lsCmd.Flags().Int("min-width", 0, "Minimum width of the category bar (0 for no minimum)")
+	lsCmd.Flags().Int("max-width", 0, "Maximum width of the category bar (0 for no maximum)")
+
+	lsCmd.MarkFlagsMutuallyExclusive("ledger", "heatmap")
+	lsCmd.MarkFlagsMutuallyExclusive("pay-period", "today")
+	lsCmd.MarkFlagsMutuallyExclusive("pay-period", "from-day")
+	lsCmd.MarkFlagsMutuallyExclusive("pay-period", "to-day")
+	lsCmd.MarkFlagsMutuallyExclusive("today", "from-day")
+	lsCmd.MarkFlagsMutuallyExclusive("today", "to-day")
+	lsCmd.MarkFlagsMutuallyExclusive("summary-format", "json")
+	lsCmd.MarkFlagsMutuallyExclusive("category-regex", "total-by")
+	lsCmd.MarkFlagsMutuallyExclusive("tree", "bar-only")
+	lsCmd.MarkFlagsMutuallyExclusive("amount-abs", "min-percent")
+	lsCmd.MarkFlagsMutuallyExclusive("only-categories", "category")
+	lsCmd.MarkFlagsMutuallyExclusive("only-categories", "uncategorized")
+}
+
+// listJSON is the document printed by 'ls --json'.
+type listJSON struct {
+	Expenses []Expense   `json:"expenses"`
+	Summary  summaryJSON `json:"summary"`
+}
+
+type summaryJSON struct {
+	Total      float64         `json:"total"`
+	Count      int             `json:"count"`
+	Average    float64         `json:"average"`
+	Categories []CategoryTotal `json:"categories"`
+}
+
+func newSummaryJSON(count int, totalAmount float64, categoryTotalsMap map[string]float64) summaryJSON {
+	average := 0.0
+	if count > 0 {
+		average = totalAmount / float64(count)
+	}
+	return summaryJSON{
+		Total:      totalAmount,
+		Count:      count,
+		Average:    average,
+		Categories: categoryTotals(totalAmount, categoryTotalsMap),
+	}
+}
+
+func newListJSON(expenses []Expense, totalAmount float64, categoryTotalsMap map[string]float64) listJSON {
+	if expenses == nil {
+		expenses = []Expense{}
+	}
+	return listJSON{
+		Expenses: expenses,
+		Summary:  newSummaryJSON(len(expenses), totalAmount, categoryTotalsMap),
+	}
+}
 
-		for i, catName := range categoryNames {
-			categoryColorMap[catName] = categoryColors[i%len(categoryColors)]
+// categoryTotals returns the per-category totals sorted by amount, largest first.
+func categoryTotals(totalAmount float64, categoryTotalsMap map[string]float64) []CategoryTotal {
+	totals := []CategoryTotal{}
+	for name, amount := range categoryTotalsMap {
+		percent := 0.0
+		if totalAmount > 0 {
+			percent = (amount / totalAmount) * 100
+		}
+		totals = append(totals, CategoryTotal{Name: name, Amount: amount, Percent: percent})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Amount != totals[j].Amount {
+			return totals[i].Amount > totals[j].Amount
 		}
+		return totals[i].Name < totals[j].Name
+	})
+	return totals
+}
 
-		renderExpenseTable(expenses, totalAmount, categoryTotalsMap, currentDay, currentMonthName, categoryColorMap, totalLineWidth)
-	},
+// tableOptions holds display settings for the ls table and summary.
+type tableOptions struct {
+	noHeader    bool
+	headerStyle string
+	heatmap     bool
+	ledger      bool
+	sumRow      bool
+	verbose     bool
+	maxRows     int
+	totalLast   bool
+
+	// noCategoryBar, noCategoryTotals and noGrandTotal hide the parts of
+	// the summary below the table.
+	noCategoryBar    bool
+	noCategoryTotals bool
+	noGrandTotal     bool
+
+	maxTitleWidth int
+	minPercent    float64
+	relative      bool
+
+	// income is the monthly income amounts are shown as a percentage of,
+	// or 0 to show absolute amounts only.
+	income float64
+
+	// secondaryRate converts amounts into secondaryCurrency for display;
+	// 0 disables the secondary amounts.
+	secondaryCurrency string
+	secondaryRate     float64
+
+	// budgets maps a category to its monthly limit, shown as a percentage
+	// next to the category total.
+	budgets map[string]float64
+
+	// categoryOrder lists category labels in the order the summary should
+	// show them; empty sorts by amount.
+	categoryOrder []string
+
+	// highlight marks titles containing this lowercased term.
+	highlight string
+
+	// categoryCase renders category names in one of the headerStyles
+	// without changing what is stored.
+	categoryCase string
+
+	// absTotals sums the absolute amounts per category for --amount-abs,
+	// which ranks categories and sizes the bar by them; nil when off.
+	absTotals map[string]float64
+
+	// barLabels writes each category's name and percentage into its bar
+	// segment when the segment is wide enough.
+	barLabels bool
+
+	// sortStable breaks ties between equal category totals by name.
+	sortStable bool
 }
 
-func renderExpenseTable(expenses []Expense, totalAmount float64, categoryTotalsMap map[string]float64, currentDay int, currentMonthName string, categoryColorMap map[string]string, totalLineWidth int) {
-	// Create new table
+// newTable creates a borderless, tab-padded table in the style used by ls.
+// A nil header leaves the table without a header row.
+func newTable(header []string, alignments []int) *tablewriter.Table {
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Title", "Amount", "Date", "Category", "Status"})
+	if header != nil {
+		table.SetHeader(header)
+	}
 	table.SetAutoWrapText(false)
 	table.SetAutoFormatHeaders(true)
 	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-
-	// Set different alignments per column
-	table.SetColumnAlignment([]int{
-		tablewriter.ALIGN_LEFT,   // Title - left aligned
-		tablewriter.ALIGN_RIGHT,  // Amount - right aligned for numbers
-		tablewriter.ALIGN_LEFT,   // Date - left aligned
-		tablewriter.ALIGN_LEFT,   // Category - left aligned
-		tablewriter.ALIGN_CENTER, // Status - center aligned
-	})
+	table.SetColumnAlignment(alignments)
 
 	table.SetCenterSeparator("")
 	table.SetColumnSeparator("")
 	table.SetRowSeparator("")
-	table.SetHeaderLine(true)
+	table.SetHeaderLine(header != nil)
 	table.SetBorder(false)
 	table.SetTablePadding("\t") // pad with tabs
 	table.SetNoWhiteSpace(true)
+	return table
+}
 
-	// Add expense data to table
-	for _, exp := range expenses {
-		statusOutput := statusIndicator
-		expenseDay := exp.Day
+// assignCategoryColors gives each category a color from the palette in name
+// order. Sub-categories share their parent's color so the bar and table
+// group visually by top-level category.
+func assignCategoryColors(categoryTotalsMap map[string]float64) map[string]string {
+	var categoryNames []string
+	for catName := range categoryTotalsMap {
+		categoryNames = append(categoryNames, catName)
+	}
+	sort.Strings(categoryNames)
 
-		if expenseDay == currentDay {
-			statusOutput = colorToday + statusIndicator + colorReset
-		} else if expenseDay < currentDay {
-			statusOutput = colorPast + statusIndicator + colorReset
-		} else {
-			dayDiffFuture := expenseDay - currentDay
-			if dayDiffFuture > 5 {
-				statusOutput = statusIndicator
-			} else if dayDiffFuture > 0 && dayDiffFuture <= 3 {
-				statusOutput = colorFutureNear + statusIndicator + colorReset
-			} else if dayDiffFuture > 3 && dayDiffFuture <= 5 {
-				statusOutput = colorFutureMid + statusIndicator + colorReset
-			} else {
-				statusOutput = statusIndicator
+	categoryColorMap := make(map[string]string)
+	parentColors := make(map[string]string)
+	for _, catName := range categoryNames {
+		parent := categoryParent(catName)
+		color, ok := parentColors[parent]
+		if !ok {
+			color = categoryColors[len(parentColors)%len(categoryColors)]
+			parentColors[parent] = color
+		}
+		categoryColorMap[catName] = color
+		categoryColorMap[parent] = color
+	}
+	return categoryColorMap
+}
+
+// headerStyles are the accepted values of --header-style.
+var headerStyles = []string{"upper", "title", "lower", "none"}
+
+// parseColorOverrides parses "category=color" pairs, where color is a name
+// from namedColors or a 256-color code, into escape sequences by category.
+func parseColorOverrides(pairs []string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, pair := range pairs {
+		category, color, ok := strings.Cut(pair, "=")
+		category, color = strings.TrimSpace(category), strings.ToLower(strings.TrimSpace(color))
+		if !ok || category == "" || color == "" {
+			return nil, fmt.Errorf("invalid color '%s', expected category=color", pair)
+		}
+		code, known := namedColors[color]
+		if !known {
+			n, err := strconv.Atoi(color)
+			if err != nil || n < 0 || n > 255 {
+				return nil, fmt.Errorf("unknown color '%s', use a color name or a 256-color code from 0 to 255", color)
 			}
+			code = n
 		}
+		overrides[category] = fmt.Sprintf("\033[38;5;%dm", code)
+	}
+	return overrides, nil
+}
 
-		displayDateStr := fmt.Sprintf("%02d %s", expenseDay, currentMonthName)
-		amountStr := fmt.Sprintf("%.2f", exp.Amount)
+// styleHeader applies a header style other than the default upper case,
+// which tablewriter applies itself. "none" keeps the names as written.
+func styleHeader(header []string, style string) []string {
+	styled := make([]string, len(header))
+	for i, name := range header {
+		styled[i] = applyCase(name, style)
+	}
+	return styled
+}
 
-		displayCategory := exp.Category
-		if displayCategory == "" {
-			displayCategory = "Uncategorized"
+// applyCase renders text in one of the headerStyles; "none" and unknown
+// styles keep it as written.
+func applyCase(text, style string) string {
+	switch style {
+	case "upper":
+		return strings.ToUpper(text)
+	case "lower":
+		return strings.ToLower(text)
+	case "title":
+		words := strings.Fields(strings.ToLower(text))
+		for i, word := range words {
+			first, size := utf8.DecodeRuneInString(word)
+			words[i] = strings.ToUpper(string(first)) + word[size:]
+		}
+		return strings.Join(words, " ")
+	default:
+		return text
+	}
+}
+
+// dueStatus returns the status indicator colored by how many days away an
+// expense is due: negative for past days and 0 for today.
+func dueStatus(dayDiff int) string {
+	switch {
+	case dayDiff == 0:
+		return colorize(colorToday, statusIndicator)
+	case dayDiff < 0:
+		return colorize(colorPast, statusIndicator)
+	case dayDiff <= 3:
+		return colorize(colorFutureNear, statusIndicator)
+	case dayDiff <= 5:
+		return colorize(colorFutureMid, statusIndicator)
+	default:
+		return statusIndicator
+	}
+}
+
+func renderExpenseTable(expenses []Expense, totalAmount float64, categoryTotalsMap map[string]float64, currentDay int, currentMonthName string, categoryColorMap map[string]string, totalLineWidth int, opts tableOptions) {
+	header := []string{"Title", "Amount", "Date", "Category", "Status"}
+	alignments := []int{
+		tablewriter.ALIGN_LEFT,   // Title - left aligned
+		tablewriter.ALIGN_RIGHT,  // Amount - right aligned for numbers
+		tablewriter.ALIGN_LEFT,   // Date - left aligned
+		tablewriter.ALIGN_LEFT,   // Category - left aligned
+		tablewriter.ALIGN_CENTER, // Status - center aligned
+	}
+	if opts.secondaryRate > 0 {
+		header = slices.Insert(header, 2, "Converted")
+		alignments = slices.Insert(alignments, 2, tablewriter.ALIGN_RIGHT)
+	}
+	if opts.verbose {
+		header = append(header, "Created")
+		alignments = append(alignments, tablewriter.ALIGN_LEFT)
+	}
+	customHeader := opts.headerStyle != "" && opts.headerStyle != "upper"
+	if customHeader {
+		header = styleHeader(header, opts.headerStyle)
+	}
+	if opts.noHeader {
+		header = nil
+	}
+	table := newTable(header, alignments)
+	if customHeader {
+		table.SetAutoFormatHeaders(false)
+	}
+
+	maxAmount := 0.0
+	if opts.heatmap {
+		for _, exp := range expenses {
+			maxAmount = math.Max(maxAmount, exp.Amount)
+		}
+	}
+
+	shown := expenses
+	if opts.maxRows > 0 && len(shown) > opts.maxRows {
+		shown = shown[:opts.maxRows]
+	}
+
+	// Add expense data to table
+	for _, exp := range shown {
+		expenseDay := exp.Day
+		statusOutput := dueStatus(expenseDay - currentDay)
+
+		displayDateStr := fmt.Sprintf("%02d %s", expenseDay, currentMonthName)
+		if opts.relative {
+			displayDateStr = relativeDay(expenseDay - currentDay)
+		}
+		if exp.Time != "" {
+			displayDateStr += " " + exp.Time
+		}
+		amountStr := formatAmount(exp.Amount)
+		if opts.ledger {
+			amountStr = ledgerAmount(exp.Amount)
+		}
+		if opts.income > 0 {
+			amountStr += fmt.Sprintf(" (%.1f%%)", exp.Amount/opts.income*100)
+		}
+		if opts.heatmap {
+			amountStr = colorize(amountColor(exp.Amount, maxAmount), amountStr)
 		}
 
+		displayCategory := categoryLabel(exp.Category)
+
 		categoryColor, ok := categoryColorMap[displayCategory]
 		if !ok {
 			categoryColor = colorReset
 		}
-		coloredCategory := fmt.Sprintf("%s%s%s", categoryColor, displayCategory, colorReset)
+		coloredCategory := colorize(categoryColor, applyCase(displayCategory, opts.categoryCase))
+
+		title := exp.Title
+		if opts.maxTitleWidth > 0 {
+			title = runewidth.Truncate(title, opts.maxTitleWidth, "…")
+		}
+		if opts.highlight != "" && strings.Contains(strings.ToLower(exp.Title), opts.highlight) {
+			title = colorize(highlight, title)
+		}
+
+		row := []string{title, amountStr, displayDateStr, coloredCategory, statusOutput}
+		if opts.secondaryRate > 0 {
+			row = slices.Insert(row, 2, formatSecondary(exp.Amount, opts))
+		}
+		if opts.verbose {
+			row = append(row, formatCreatedAt(exp.CreatedAt))
+		}
+		table.Append(row)
+	}
 
-		table.Append([]string{exp.Title, amountStr, displayDateStr, coloredCategory, statusOutput})
+	if opts.sumRow {
+		totalStr := formatAmount(totalAmount)
+		if opts.ledger {
+			totalStr = ledgerAmount(totalAmount)
+		}
+		row := []string{colorize(bold, "TOTAL"), colorize(bold, totalStr), "", "", ""}
+		if opts.secondaryRate > 0 {
+			row = slices.Insert(row, 2, colorize(bold, formatSecondary(totalAmount, opts)))
+		}
+		if opts.verbose {
+			row = append(row, "")
+		}
+		table.Append(row)
 	}
 
 	// Render the table
 	table.Render()
+	if hidden := len(expenses) - len(shown); hidden > 0 {
+		fmt.Printf("… and %d more (raise --max-rows or use filters)\n", hidden)
+	}
+
+	renderSummary(totalAmount, categoryTotalsMap, categoryColorMap, totalLineWidth, opts)
+}
+
+// renderSummary prints the category bar followed by the summary totals.
+func renderSummary(totalAmount float64, categoryTotalsMap map[string]float64, categoryColorMap map[string]string, totalLineWidth int, opts tableOptions) {
+	if opts.minPercent > 0 {
+		categoryTotalsMap = collapseSmallCategories(categoryTotalsMap, totalAmount, opts.minPercent)
+		if _, ok := categoryColorMap[otherCategory]; !ok {
+			categoryColorMap[otherCategory] = colorOther
+		}
+	}
+
+	// With --amount-abs categories are ranked and the bar is sized by
+	// magnitude, so refunds don't shrink a category; totals stay signed.
+	rankTotals, barTotal := categoryTotalsMap, totalAmount
+	if opts.absTotals != nil {
+		rankTotals, barTotal = opts.absTotals, 0
+		for _, amount := range opts.absTotals {
+			barTotal += amount
+		}
+	}
 
 	// Generate and display category visualization line
 	var categories []string
 	for cat := range categoryTotalsMap {
 		categories = append(categories, cat)
 	}
+	sortCategories(categories, rankTotals, opts)
+
+	barCategories, barTotals := categories, rankTotals
+	if rollup, ok := rollupCategories(rankTotals, opts); ok {
+		barCategories, barTotals = rollup.parents, rollup.parentTotals
+	}
+	if !opts.noCategoryBar {
+		coloredLine := generateColoredLine(barCategories, barTotals, barTotal, categoryColorMap, totalLineWidth, opts)
+		fmt.Println(coloredLine)
+	}
+
+	printSummaryTotals(totalAmount, categories, categoryTotalsMap, categoryColorMap, opts)
+}
+
+// sortCategories orders category labels by total, largest first, and then
+// by opts.categoryOrder when one is given.
+func sortCategories(categories []string, totals map[string]float64, opts tableOptions) {
 	sort.Slice(categories, func(i, j int) bool {
-		return categoryTotalsMap[categories[i]] > categoryTotalsMap[categories[j]]
+		if opts.sortStable && totals[categories[i]] == totals[categories[j]] {
+			return categories[i] < categories[j]
+		}
+		return totals[categories[i]] > totals[categories[j]]
 	})
+	if len(opts.categoryOrder) > 0 {
+		sort.SliceStable(categories, func(i, j int) bool {
+			return categoryRank(opts.categoryOrder, categories[i]) < categoryRank(opts.categoryOrder, categories[j])
+		})
+	}
+}
 
-	coloredLine := generateColoredLine(categories, categoryTotalsMap, totalAmount, categoryColorMap, totalLineWidth)
-	fmt.Println(coloredLine)
+// categoryRank is the position of a category label in order; labels that
+// are not listed, such as "Other", sort last.
+func categoryRank(order []string, label string) int {
+	if i := slices.Index(order, label); i >= 0 {
+		return i
+	}
+	return len(order)
+}
 
-	printSummaryTotals(totalAmount, categories, categoryTotalsMap, categoryColorMap)
+// collapseSmallCategories merges categories below minPercent of the total
+// into a single "Other" entry.
+func collapseSmallCategories(categoryTotalsMap map[string]float64, totalAmount, minPercent float64) map[string]float64 {
+	if totalAmount <= 0 {
+		return categoryTotalsMap
+	}
+	collapsed := make(map[string]float64)
+	for cat, amount := range categoryTotalsMap {
+		if amount/totalAmount*100 < minPercent {
+			collapsed[otherCategory] += amount
+		} else {
+			collapsed[cat] += amount
+		}
+	}
+	return collapsed
+}
+
+// lastPayday returns the most recent payday on or before now. A payday past
+// the end of a short month falls on its last day.
+func lastPayday(now time.Time, payday int) time.Time {
+	year, month := now.Year(), now.Month()
+	if now.Day() < payday {
+		month--
+	}
+	// Day 0 of the following month is the last day of this one.
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	return time.Date(year, month, min(payday, lastDay), 0, 0, 0, 0, now.Location())
+}
+
+// clampWidth keeps the category bar width within the given bounds; a bound
+// of 0 leaves that side unlimited.
+func clampWidth(width, minWidth, maxWidth int) int {
+	if minWidth > 0 {
+		width = max(width, minWidth)
+	}
+	if maxWidth > 0 {
+		width = min(width, maxWidth)
+	}
+	return width
 }
 
-func generateColoredLine(categories []string, categoryTotalsMap map[string]float64, totalAmount float64, categoryColorMap map[string]string, totalLineWidth int) string {
+func generateColoredLine(categories []string, categoryTotalsMap map[string]float64, totalAmount float64, categoryColorMap map[string]string, totalLineWidth int, opts tableOptions) string {
 	var coloredLine strings.Builder
 	remainingWidth := totalLineWidth
 
@@ -204,47 +919,174 @@ func generateColoredLine(categories []string, categoryTotalsMap map[string]float
 			percentage = (categoryTotal / totalAmount) * 100
 		}
 
-		segmentLength := min(int(math.Round(percentage/100.0*float64(totalLineWidth))), remainingWidth)
+		// Negative totals, e.g. a category of refunds, get no segment.
+		segmentLength := max(min(int(math.Round(percentage/100.0*float64(totalLineWidth))), remainingWidth), 0)
+		// The last segment fills whatever rounding left over.
+		if i == len(categories)-1 {
+			segmentLength = remainingWidth
+		}
 
 		categoryColor, ok := categoryColorMap[cat]
 		if !ok {
 			categoryColor = colorReset
 		}
 
-		coloredLine.WriteString(categoryColor)
-		coloredLine.WriteString(strings.Repeat(lineCharacter, segmentLength))
-		coloredLine.WriteString(colorReset)
-		remainingWidth -= segmentLength
-
-		if i == len(categories)-1 && remainingWidth > 0 {
-			coloredLine.WriteString(categoryColor)
-			coloredLine.WriteString(strings.Repeat(lineCharacter, remainingWidth))
-			coloredLine.WriteString(colorReset)
+		segment := strings.Repeat(lineCharacter, segmentLength)
+		if opts.barLabels {
+			segment = labelSegment(fmt.Sprintf(" %s %.0f%% ", applyCase(cat, opts.categoryCase), percentage), segmentLength)
 		}
+		coloredLine.WriteString(colorize(categoryColor, segment))
+		remainingWidth -= segmentLength
 	}
 	return coloredLine.String()
 }
 
-func printSummaryTotals(totalAmount float64, categories []string, categoryTotalsMap map[string]float64, categoryColorMap map[string]string) {
-	fmt.Printf("\nTotal Amount: %.2f\n", totalAmount)
+// labelSegment centers label in a bar segment of the given width, or
+// returns the plain segment when the label does not fit.
+func labelSegment(label string, width int) string {
+	labelWidth := runewidth.StringWidth(label)
+	if labelWidth > width {
+		return strings.Repeat(lineCharacter, width)
+	}
+	left := (width - labelWidth) / 2
+	return strings.Repeat(lineCharacter, left) + label + strings.Repeat(lineCharacter, width-labelWidth-left)
+}
+
+// printSummaryTotals prints the grand total and per-category totals. With
+// opts.totalLast the categories come first and the grand total is printed
+// below a rule line.
+func printSummaryTotals(totalAmount float64, categories []string, categoryTotalsMap map[string]float64, categoryColorMap map[string]string, opts tableOptions) {
+	if opts.noCategoryTotals && opts.noGrandTotal {
+		return
+	}
+
+	totalLine := fmt.Sprintf("Total Amount: %s%s%s", formatAmount(totalAmount), secondaryShare(totalAmount, opts), incomeShare(totalAmount, opts.income))
+	fmt.Println()
+	if !opts.totalLast && !opts.noGrandTotal {
+		fmt.Println(totalLine)
+	}
+
+	printCategoryLine := func(indent, cat, name string, categoryTotal float64) {
+		percentage := 0.0
+		if totalAmount > 0 {
+			percentage = (categoryTotal / totalAmount) * 100
+		}
+
+		categoryColor, ok := categoryColorMap[cat]
+		if !ok {
+			categoryColor = colorReset
+		}
+		coloredCatName := colorize(categoryColor, applyCase(name, opts.categoryCase))
+
+		fmt.Printf("%s- %s: %s%s (%.1f%%)%s%s\n", indent, coloredCatName, formatAmount(categoryTotal), secondaryShare(categoryTotal, opts), percentage, incomeShare(categoryTotal, opts.income), budgetStatus(categoryTotal, opts.budgets[cat]))
+	}
 
-	if len(categoryTotalsMap) > 0 {
+	if len(categoryTotalsMap) > 0 && !opts.noCategoryTotals {
 		fmt.Println("Category Totals:")
 
-		for _, cat := range categories {
-			categoryTotal := categoryTotalsMap[cat]
-			percentage := 0.0
-			if totalAmount > 0 {
-				percentage = (categoryTotal / totalAmount) * 100
+		if rollup, ok := rollupCategories(categoryTotalsMap, opts); ok {
+			for _, parent := range rollup.parents {
+				printCategoryLine("  ", parent, parent, rollup.parentTotals[parent])
+				for _, child := range rollup.children[parent] {
+					printCategoryLine("      ", child, strings.TrimPrefix(child, parent+cfg.CategorySeparator), categoryTotalsMap[child])
+				}
 			}
-
-			categoryColor, ok := categoryColorMap[cat]
-			if !ok {
-				categoryColor = colorReset
+		} else {
+			for _, cat := range categories {
+				printCategoryLine("  ", cat, cat, categoryTotalsMap[cat])
 			}
-			coloredCatName := fmt.Sprintf("%s%s%s", categoryColor, cat, colorReset)
+		}
+	}
+
+	if opts.totalLast && !opts.noGrandTotal {
+		fmt.Println(strings.Repeat(lineCharacter, utf8.RuneCountInString(totalLine)))
+		fmt.Println(totalLine)
+	}
+}
+
+// formatSecondary converts an amount into the secondary currency.
+func formatSecondary(amount float64, opts tableOptions) string {
+	return fmt.Sprintf("%s%.2f", opts.secondaryCurrency, amount*opts.secondaryRate)
+}
+
+// secondaryShare appends the secondary-currency amount to a summary line.
+// It is empty when no conversion rate is set.
+func secondaryShare(amount float64, opts tableOptions) string {
+	if opts.secondaryRate <= 0 {
+		return ""
+	}
+	return " (≈ " + formatSecondary(amount, opts) + ")"
+}
+
+// incomeShare describes an amount as a percentage of monthly income. It is
+// empty when income percentages are not requested.
+func incomeShare(amount, income float64) string {
+	if income <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [%.1f%% of income]", amount/income*100)
+}
+
+// budgetStatus describes spend as a percentage of a category's budget,
+// highlighted when over. It is empty for categories without a budget.
+func budgetStatus(spent, budget float64) string {
+	if budget <= 0 {
+		return ""
+	}
+	status := budgetUsage(spent, budget)
+	if spent > budget {
+		status = colorize(colorOverBudget, status)
+	}
+	return " [" + status + "]"
+}
+
+// formatCreatedAt shows a stored UTC entry timestamp in the display
+// timezone, or "-" for rows entered before timestamps were recorded.
+func formatCreatedAt(createdAt string) string {
+	if createdAt == "" {
+		return "-"
+	}
+	t, err := time.ParseInLocation(time.DateTime, createdAt, time.UTC)
+	if err != nil {
+		return createdAt
+	}
+	return t.In(location).Format("2006-01-02 15:04")
+}
+
+// ledgerAmount shows an amount the way accounting software does: a stored
+// expense is a debit, shown negative in red, and a negative amount such as
+// a refund is a credit, shown positive in green.
+func ledgerAmount(amount float64) string {
+	if amount < 0 {
+		return colorize(colorCredit, "+"+formatAmount(-amount))
+	}
+	return colorize(colorDebit, "-"+formatAmount(amount))
+}
+
+// budgetUsage describes spend as a percentage of a budget, without color.
+func budgetUsage(spent, budget float64) string {
+	return fmt.Sprintf("%.1f%% of %s budget", spent/budget*100, formatAmount(budget))
+}
+
+// formatAmount renders an amount with two decimals, prefixed by the
+// configured currency symbol if one is set. With compactAmounts, amounts
+// from the configured threshold up are shortened, e.g. 1.2k or 3.4M.
+func formatAmount(amount float64) string {
+	if compactAmounts && math.Abs(amount) >= cfg.CompactThreshold {
+		return cfg.Currency + compactNumber(amount)
+	}
+	return fmt.Sprintf("%s%.2f", cfg.Currency, amount)
+}
 
-			fmt.Printf("  - %s: %.2f (%.1f%%)\n", coloredCatName, categoryTotal, percentage)
+// compactNumber formats a number with an SI-style suffix.
+func compactNumber(amount float64) string {
+	for _, unit := range []struct {
+		size   float64
+		suffix string
+	}{{1e9, "G"}, {1e6, "M"}, {1e3, "k"}} {
+		if math.Abs(amount) >= unit.size {
+			return strconv.FormatFloat(amount/unit.size, 'f', 1, 64) + unit.suffix
 		}
 	}
+	return fmt.Sprintf("%.2f", amount)
 }
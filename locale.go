@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// monthNames holds month names for the supported display locales, indexed
+// by time.Month - 1. English is the default.
+var monthNames = map[string][12]string{
+	"en": {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+	"es": {"Enero", "Febrero", "Marzo", "Abril", "Mayo", "Junio", "Julio", "Agosto", "Septiembre", "Octubre", "Noviembre", "Diciembre"},
+	"fr": {"Janvier", "Février", "Mars", "Avril", "Mai", "Juin", "Juillet", "Août", "Septembre", "Octobre", "Novembre", "Décembre"},
+	"it": {"Gennaio", "Febbraio", "Marzo", "Aprile", "Maggio", "Giugno", "Luglio", "Agosto", "Settembre", "Ottobre", "Novembre", "Dicembre"},
+	"nl": {"Januari", "Februari", "Maart", "April", "Mei", "Juni", "Juli", "Augustus", "September", "Oktober", "November", "December"},
+	"pt": {"Janeiro", "Fevereiro", "Março", "Abril", "Maio", "Junho", "Julho", "Agosto", "Setembro", "Outubro", "Novembro", "Dezembro"},
+}
+
+// monthName returns the name of a month in the configured locale.
+func monthName(month time.Month) string {
+	names, ok := monthNames[cfg.Locale]
+	if !ok {
+		names = monthNames["en"]
+	}
+	return names[month-1]
+}
+
+func supportedLocales() []string {
+	var locales []string
+	for locale := range monthNames {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
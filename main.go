@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -10,8 +12,13 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "monke",
 	Short: "Monke is a simple expense tracker CLI",
-	PersistentPreRun: func(_ *cobra.Command, _ []string) {
+	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+		if ephemeral, _ := cmd.Flags().GetBool("ephemeral"); ephemeral {
+			dbFlag = memoryDSN
+		}
+		loadConfig()
 		initDB()
+		handleInterrupts(cmd)
 	},
 	PersistentPostRun: func(_ *cobra.Command, _ []string) {
 		if db != nil {
@@ -20,10 +27,73 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+var prettyJSON bool
+
+// requireWritable stops a command that modifies the database when the
+// database was opened with --read-only.
+func requireWritable(cmd *cobra.Command) {
+	if readOnly {
+		log.Fatalf("Error: '%s' modifies the database and cannot run with --read-only.", cmd.CommandPath())
+	}
+}
+
+// marshalJSON encodes v as compact JSON, or indented with --pretty.
+func marshalJSON(v any) []byte {
+	var (
+		out []byte
+		err error
+	)
+	if prettyJSON {
+		out, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		out, err = json.Marshal(v)
+	}
+	if err != nil {
+		log.Fatalf("Error encoding JSON: %v", err)
+	}
+	return out
+}
+
+// printJSON writes v to stdout as JSON.
+func printJSON(v any) {
+	fmt.Println(string(marshalJSON(v)))
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", os.Getenv("NO_COLOR") != "", "Disable colored output")
+	rootCmd.PersistentFlags().StringVar(&timezoneFlag, "tz", "", "Timezone used to determine today, e.g. Europe/Berlin (default local)")
+	rootCmd.PersistentFlags().StringVar(&statusGlyphFlag, "status-glyph", "", "Character used for the due status indicator (default ●)")
+	rootCmd.PersistentFlags().StringVar(&barCharFlag, "bar-char", "", "Character used to draw the category bar (default ■)")
+	rootCmd.PersistentFlags().BoolVar(&asciiOutput, "ascii", false, "Use ASCII * and # for the status indicator and category bar")
+	rootCmd.PersistentFlags().StringVar(&nowFlag, "now", os.Getenv("MONKE_NOW"), "Treat this date (YYYY-MM-DD) as today, e.g. to review a past month (default $MONKE_NOW or the real clock)")
+	rootCmd.PersistentFlags().BoolVar(&prettyJSON, "pretty", false, "Indent JSON output")
+	rootCmd.PersistentFlags().StringVar(&dbFlag, "db", "", "Database file to use, or :memory: for a throwaway in-memory database (default ~/.config/monke/monke.db)")
+	rootCmd.PersistentFlags().Bool("ephemeral", false, "Use a throwaway in-memory database; shorthand for --db :memory:")
+	rootCmd.PersistentFlags().StringVar(&seedPath, "seed", "", "Seed the in-memory database from an SQL dump written by 'export --format sql' (- reads stdin)")
+	rootCmd.MarkFlagsMutuallyExclusive("db", "ephemeral")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "Open the database read-only; commands that modify it refuse to run")
+	rootCmd.PersistentFlags().BoolVar(&categoryRequiredFlag, "category-required", false, "Reject expenses without a category in add, edit, import, merge and recategorize (default from config category_required)")
+	rootCmd.PersistentFlags().BoolVar(&explainSQL, "explain", false, "Print executed SQL and its parameters to stderr")
+}
+
 func main() {
 	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(lsCmd)
 	rootCmd.AddCommand(clearCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(budgetCmd)
+	rootCmd.AddCommand(upcomingCmd)
+	rootCmd.AddCommand(reindexCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(categoriesCmd)
+	rootCmd.AddCommand(recategorizeCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(snapshotCmd)
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <other.db>",
+	Short: "Merge expenses from another monke database",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireWritable(cmd)
+
+		dedupe, _ := cmd.Flags().GetBool("dedupe")
+		continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+		otherPath := args[0]
+
+		other := openOtherDB(otherPath)
+		defer other.Close()
+
+		rewriter := newAliasRewriter(newCategoryAliases(cfg.CategoryAliases, nil))
+		incoming, rowErrors := readMergeExpenses(other, rewriter)
+		if len(rowErrors) > 0 {
+			for _, rowErr := range rowErrors {
+				fmt.Fprintf(os.Stderr, "row %d: %v\n", rowErr.line, rowErr.err)
+			}
+			if !continueOnError {
+				log.Fatalf("Error: %d row(s) in %s are not valid expenses; nothing was merged.", len(rowErrors), otherPath)
+			}
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Fatalf("Error starting transaction: %v", err)
+		}
+		defer tx.Rollback()
+
+		dupSQL := `SELECT COUNT(*) FROM expenses WHERE title = ? AND amount = ? AND day = ? AND IFNULL(category, '') = ?`
+
+		inserted, skipped := 0, 0
+		for _, exp := range incoming {
+			if dedupe {
+				var count int
+				explain(dupSQL, exp.Title, exp.Amount, exp.Day, exp.Category)
+				err := tx.QueryRow(dupSQL, exp.Title, exp.Amount, exp.Day, exp.Category).Scan(&count)
+				if err != nil {
+					log.Fatalf("Error checking for duplicates: %v", err)
+				}
+				if count > 0 {
+					skipped++
+					continue
+				}
+			}
+
+			if _, err := insertExpense(tx, exp); err != nil {
+				log.Fatalf("Error inserting expense '%s': %v", exp.Title, err)
+			}
+			inserted++
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("Error committing merge: %v", err)
+		}
+
+		fmt.Printf("Merged %s: %d inserted, %d skipped.\n", otherPath, inserted, skipped+len(rowErrors))
+		printAliasMappings(rewriter.mapped)
+	},
+}
+
+// openOtherDB opens another monke database read-only.
+func openOtherDB(path string) *sql.DB {
+	if _, err := os.Stat(path); err != nil {
+		log.Fatalf("Error opening database '%s': %v", path, err)
+	}
+	other, err := sql.Open("sqlite3", readOnlyDSN(path))
+	if err != nil {
+		log.Fatalf("Error opening database '%s': %v", path, err)
+	}
+	return other
+}
+
+// readMergeExpenses reads all expenses from another database, filling in
+// columns that its (possibly older) schema does not have. Rows are validated
+// like imported ones, after their categories are resolved, and a row error
+// is collected for each that fails. A nil rewriter reads rows as stored.
+func readMergeExpenses(other *sql.DB, rewriter *aliasRewriter) ([]Expense, []importRowError) {
+	columns, err := expenseTableColumns(other)
+	if err != nil {
+		log.Fatalf("Error reading schema of other database: %v", err)
+	}
+	if len(columns) == 0 {
+		log.Fatalf("Error: Other database has no expenses table.")
+	}
+
+	selected := []string{"title", "amount", "day"}
+	for _, name := range selected {
+		if _, ok := columns[name]; !ok {
+			log.Fatalf("Error: Other database is missing the required '%s' column.", name)
+		}
+	}
+	for _, name := range []string{"category", "time"} {
+		if _, ok := columns[name]; ok {
+			selected = append(selected, name)
+		} else {
+			selected = append(selected, "NULL")
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM expenses ORDER BY id ASC", strings.Join(selected, ", "))
+	explain(query)
+	rows, err := other.Query(query)
+	if err != nil {
+		log.Fatalf("Error querying other database: %v", err)
+	}
+	defer rows.Close()
+
+	var expenses []Expense
+	var rowErrors []importRowError
+	for row := 1; rows.Next(); row++ {
+		// Values are read as text, as another database may hold any type
+		// in any column.
+		var title, amount, day, category, timeOfDay sql.NullString
+		if err := rows.Scan(&title, &amount, &day, &category, &timeOfDay); err != nil {
+			log.Fatalf("Error scanning row: %v", err)
+		}
+		exp, err := parseMergeRow(title, amount, day)
+		exp.Category = category.String
+		exp.Time = timeOfDay.String
+		if err == nil && rewriter != nil {
+			err = rewriter.validate(&exp)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, importRowError{row, err})
+			continue
+		}
+		expenses = append(expenses, exp)
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatalf("Error iterating rows: %v", err)
+	}
+	return expenses, rowErrors
+}
+
+// parseMergeRow converts the title, amount and day of a row read as text,
+// rejecting values that are missing or not numbers.
+func parseMergeRow(title, amount, day sql.NullString) (Expense, error) {
+	exp := Expense{Title: title.String}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(amount.String), 64)
+	if !amount.Valid || err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return exp, fmt.Errorf("invalid amount '%s'", amount.String)
+	}
+	exp.Amount = parsed
+	exp.Day, err = strconv.Atoi(strings.TrimSpace(day.String))
+	if !day.Valid || err != nil {
+		return exp, fmt.Errorf("invalid day '%s'", day.String)
+	}
+	return exp, nil
+}
+
+func init() {
+	mergeCmd.Flags().Bool("dedupe", false, "Skip expenses matching an existing title, amount, day and category")
+	mergeCmd.Flags().Bool("continue-on-error", false, "Skip rows that are not valid expenses and merge the rest")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var recategorizeCmd = &cobra.Command{
+	Use:   "recategorize",
+	Short: "Move every expense matching a search into another category",
+	Run: func(cmd *cobra.Command, _ []string) {
+		search, _ := cmd.Flags().GetString("search")
+		to, _ := cmd.Flags().GetString("to")
+		to = resolveCategory(to)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if !dryRun {
+			requireWritable(cmd)
+		}
+
+		if search == "" {
+			log.Fatal("Error: search flag must not be empty.")
+		}
+		if to == "" && categoryRequired() {
+			log.Fatal("Error: category is required, so --to cannot remove it.")
+		}
+
+		filter := expenseFilter{search: search}
+		where, args := filter.where()
+
+		if dryRun {
+			expenses := queryExpenses(selectExpensesSQL+where+" ORDER BY id ASC", args...)
+			if len(expenses) == 0 {
+				fmt.Println("No matching expenses found.")
+				return
+			}
+			table := newTable(
+				[]string{"ID", "Title", "Amount", "Category", "New Category"},
+				[]int{tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT},
+			)
+			for _, exp := range expenses {
+				table.Append([]string{
+					strconv.Itoa(exp.ID),
+					exp.Title,
+					formatAmount(exp.Amount),
+					categoryLabel(exp.Category),
+					categoryLabel(to),
+				})
+			}
+			table.Render()
+			fmt.Printf("Dry run: %d expense(s) would be recategorized.\n", len(expenses))
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Fatalf("Error starting transaction: %v", err)
+		}
+		defer tx.Rollback()
+
+		var category any
+		if to != "" {
+			category = to
+		}
+		updateSQL := "UPDATE expenses SET category = ?" + where
+		updateArgs := append([]any{category}, args...)
+		explain(updateSQL, updateArgs...)
+		result, err := tx.Exec(updateSQL, updateArgs...)
+		if err != nil {
+			log.Fatalf("Error updating expenses: %v", err)
+		}
+		changed, err := result.RowsAffected()
+		if err != nil {
+			log.Fatalf("Error reading update result: %v", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("Error committing recategorize: %v", err)
+		}
+
+		fmt.Printf("Moved %d expense(s) to %s.\n", changed, categoryLabel(to))
+	},
+}
+
+func init() {
+	recategorizeCmd.Flags().StringP("search", "s", "", "Recategorize expenses whose title contains this text, ignoring case (required)")
+	recategorizeCmd.Flags().String("to", "", "Category to move the matching expenses to; \"\" removes the category (required)")
+	recategorizeCmd.Flags().Bool("dry-run", false, "Show the affected expenses without changing anything")
+	recategorizeCmd.MarkFlagRequired("search")
+	recategorizeCmd.MarkFlagRequired("to")
+}
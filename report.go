@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const reportTopCount = 5
+
+var reportFormats = []string{"text", "markdown", "html"}
+
+// report holds everything shown in a monthly report.
+type report struct {
+	title             string
+	expenses          []Expense
+	currentDay        int
+	monthName         string
+	totalAmount       float64
+	categoryTotalsMap map[string]float64
+	budgets           map[string]float64
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Print a full monthly report: expenses, totals, budgets and top expenses",
+	Run: func(cmd *cobra.Command, _ []string) {
+		monthFlag, _ := cmd.Flags().GetString("month")
+		format, _ := cmd.Flags().GetString("format")
+		outPath, _ := cmd.Flags().GetString("out")
+
+		if !slices.Contains(reportFormats, format) {
+			log.Fatalf("Error: Invalid format '%s'. Valid formats: %s.", format, strings.Join(reportFormats, ", "))
+		}
+		if outPath != "" && format == "text" {
+			log.Fatal("Error: --out requires --format markdown or html.")
+		}
+
+		now := currentTime()
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+		if monthFlag != "" {
+			parsed, err := time.ParseInLocation("2006-01", monthFlag, location)
+			if err != nil {
+				log.Fatalf("Error: Invalid month '%s'. Please use the YYYY-MM format.", monthFlag)
+			}
+			month = parsed
+		}
+
+		// Expenses recur monthly, so the month only decides which of them
+		// have already been paid.
+		currentDay := now.Day()
+		switch thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location); {
+		case month.Before(thisMonth):
+			currentDay = 32
+		case month.After(thisMonth):
+			currentDay = 0
+		}
+
+		r := report{
+			title:             fmt.Sprintf("%s %d", monthName(month.Month()), month.Year()),
+			expenses:          queryExpenses(selectExpensesSQL + orderByDueSQL),
+			currentDay:        currentDay,
+			monthName:         monthName(month.Month()),
+			categoryTotalsMap: make(map[string]float64),
+			budgets:           loadBudgets(),
+		}
+		for _, exp := range r.expenses {
+			r.categoryTotalsMap[categoryLabel(exp.Category)] += exp.Amount
+			r.totalAmount += exp.Amount
+		}
+
+		if format == "text" {
+			printTextReport(r)
+			return
+		}
+
+		var out io.Writer = os.Stdout
+		if outPath != "" {
+			file, err := os.Create(outPath)
+			if err != nil {
+				log.Fatalf("Error creating report file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		var err error
+		if format == "html" {
+			err = writeHTMLReport(out, r)
+		} else {
+			err = writeMarkdownReport(out, r)
+		}
+		if err != nil {
+			log.Fatalf("Error writing report: %v", err)
+		}
+
+		if outPath != "" {
+			fmt.Printf("Report for %s written to %s.\n", r.title, outPath)
+		}
+	},
+}
+
+// topExpenses returns the n largest expenses, earliest day first on ties.
+func topExpenses(expenses []Expense, n int) []Expense {
+	top := slices.Clone(expenses)
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Amount > top[j].Amount
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+func printTextReport(r report) {
+	fmt.Printf("Expense report for %s\n\n", r.title)
+	if len(r.expenses) == 0 {
+		printNoExpenses()
+		return
+	}
+
+	opts := tableOptions{budgets: r.budgets}
+	renderExpenseTable(r.expenses, r.totalAmount, r.categoryTotalsMap, r.currentDay, r.monthName, assignCategoryColors(r.categoryTotalsMap), cfg.Width, opts)
+
+	fmt.Printf("\nTop %d Expenses:\n", reportTopCount)
+	for i, exp := range topExpenses(r.expenses, reportTopCount) {
+		fmt.Printf("  %d. %s: %s (%02d %s)\n", i+1, exp.Title, formatAmount(exp.Amount), exp.Day, r.monthName)
+	}
+}
+
+// reportStatus describes whether an expense has been paid this month.
+func reportStatus(day, currentDay int) string {
+	switch {
+	case day < currentDay:
+		return "Paid"
+	case day == currentDay:
+		return "Due today"
+	default:
+		return "Upcoming"
+	}
+}
+
+// reportCategoryLine describes a category total with its share and budget.
+func reportCategoryLine(r report, cat CategoryTotal) string {
+	line := fmt.Sprintf("%s (%.1f%%)", formatAmount(cat.Amount), cat.Percent)
+	if budget := r.budgets[cat.Name]; budget > 0 {
+		line += " — " + budgetUsage(cat.Amount, budget)
+		if cat.Amount > budget {
+			line += ", over budget"
+		}
+	}
+	return line
+}
+
+func writeMarkdownReport(w io.Writer, r report) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Expense report for %s\n\n", r.title)
+	if len(r.expenses) == 0 {
+		b.WriteString("No expenses found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("## Expenses\n\n")
+	b.WriteString("| Title | Amount | Date | Category | Status |\n")
+	b.WriteString("| --- | ---: | --- | --- | --- |\n")
+	for _, exp := range r.expenses {
+		fmt.Fprintf(&b, "| %s | %s | %02d %s | %s | %s |\n", escape(exp.Title), formatAmount(exp.Amount), exp.Day, r.monthName, escape(categoryLabel(exp.Category)), reportStatus(exp.Day, r.currentDay))
+	}
+
+	b.WriteString("\n## Summary\n\n")
+	fmt.Fprintf(&b, "**Total Amount:** %s\n\n", formatAmount(r.totalAmount))
+	for _, cat := range categoryTotals(r.totalAmount, r.categoryTotalsMap) {
+		fmt.Fprintf(&b, "- **%s:** %s\n", escape(cat.Name), reportCategoryLine(r, cat))
+	}
+
+	fmt.Fprintf(&b, "\n## Top %d Expenses\n\n", reportTopCount)
+	for i, exp := range topExpenses(r.expenses, reportTopCount) {
+		fmt.Fprintf(&b, "%d. %s: %s (%02d %s)\n", i+1, escape(exp.Title), formatAmount(exp.Amount), exp.Day, r.monthName)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeHTMLReport(w io.Writer, r report) error {
+	escape := html.EscapeString
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>Expense report for %s</title>\n", escape(r.title))
+	b.WriteString("<style>body{font-family:sans-serif}table{border-collapse:collapse}th,td{padding:4px 8px;border-bottom:1px solid #ddd;text-align:left}td.amount{text-align:right}.over{color:#c00}</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	fmt.Fprintf(&b, "<h1>Expense report for %s</h1>\n", escape(r.title))
+
+	if len(r.expenses) == 0 {
+		b.WriteString("<p>No expenses found.</p>\n</body>\n</html>\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	b.WriteString("<h2>Expenses</h2>\n<table>\n")
+	b.WriteString("<tr><th>Title</th><th>Amount</th><th>Date</th><th>Category</th><th>Status</th></tr>\n")
+	for _, exp := range r.expenses {
+		fmt.Fprintf(&b, "<tr><td>%s</td><td class=\"amount\">%s</td><td>%02d %s</td><td>%s</td><td>%s</td></tr>\n",
+			escape(exp.Title), escape(formatAmount(exp.Amount)), exp.Day, escape(r.monthName), escape(categoryLabel(exp.Category)), reportStatus(exp.Day, r.currentDay))
+	}
+	b.WriteString("</table>\n")
+
+	b.WriteString("<h2>Summary</h2>\n")
+	fmt.Fprintf(&b, "<p><strong>Total Amount:</strong> %s</p>\n<ul>\n", escape(formatAmount(r.totalAmount)))
+	for _, cat := range categoryTotals(r.totalAmount, r.categoryTotalsMap) {
+		class := ""
+		if budget := r.budgets[cat.Name]; budget > 0 && cat.Amount > budget {
+			class = ` class="over"`
+		}
+		fmt.Fprintf(&b, "<li%s><strong>%s:</strong> %s</li>\n", class, escape(cat.Name), escape(reportCategoryLine(r, cat)))
+	}
+	b.WriteString("</ul>\n")
+
+	fmt.Fprintf(&b, "<h2>Top %d Expenses</h2>\n<ol>\n", reportTopCount)
+	for _, exp := range topExpenses(r.expenses, reportTopCount) {
+		fmt.Fprintf(&b, "<li>%s: %s (%02d %s)</li>\n", escape(exp.Title), escape(formatAmount(exp.Amount)), exp.Day, escape(r.monthName))
+	}
+	b.WriteString("</ol>\n</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func init() {
+	reportCmd.Flags().String("month", "", "Month to report on, as YYYY-MM (default current month)")
+	reportCmd.Flags().StringP("format", "f", "text", "Output format: text, markdown or html")
+	reportCmd.Flags().StringP("out", "o", "", "Write the markdown or html report to this file instead of stdout")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect written by 'ls --json-schema'.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// listJSONSchema describes the document printed by 'ls --json'. It is
+// derived from the json struct tags, so it follows listJSON and Expense.
+func listJSONSchema() map[string]any {
+	schema := typeSchema(reflect.TypeOf(listJSON{}))
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = "monke ls --json"
+	return schema
+}
+
+// typeSchema builds the JSON Schema of a type as encoding/json would write
+// it. Fields tagged omitempty are optional; all others are required.
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = typeSchema(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	panic(fmt.Sprintf("typeSchema: unsupported type %s", t))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// ftsAvailable reports whether the expenses_fts full-text index is in use.
+// It requires a binary built with the sqlite_fts5 tag, as the justfile and
+// release builds are; otherwise searches fall back to LIKE.
+var ftsAvailable bool
+
+// ftsTokenizer splits titles into trigrams, so the index matches any
+// substring of three or more characters, just like the LIKE fallback.
+const ftsTokenizer = "trigram"
+
+// ftsMinTermLength is the shortest term the trigram index can match;
+// shorter terms are searched with LIKE.
+const ftsMinTermLength = 3
+
+var ftsTriggers = []struct {
+	name string
+	body string
+}{
+	{"expenses_fts_ai", `AFTER INSERT ON expenses BEGIN
+		INSERT INTO expenses_fts(rowid, title) VALUES (new.id, new.title);
+	END`},
+	{"expenses_fts_ad", `AFTER DELETE ON expenses BEGIN
+		INSERT INTO expenses_fts(expenses_fts, rowid, title) VALUES ('delete', old.id, old.title);
+	END`},
+	{"expenses_fts_au", `AFTER UPDATE ON expenses BEGIN
+		INSERT INTO expenses_fts(expenses_fts, rowid, title) VALUES ('delete', old.id, old.title);
+		INSERT INTO expenses_fts(rowid, title) VALUES (new.id, new.title);
+	END`},
+}
+
+// setupFTS creates the full-text index and the triggers keeping it in sync
+// with expenses when FTS5 is compiled in.
+func setupFTS() {
+	var enabled bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled); err != nil {
+		log.Fatalf("Error checking for FTS5 support: %v", err)
+	}
+	if !enabled {
+		// A database last opened by an FTS5 build still has triggers writing
+		// to the index, which would make every write fail here.
+		for _, trigger := range ftsTriggers {
+			if _, err := db.Exec("DROP TRIGGER IF EXISTS " + trigger.name); err != nil {
+				log.Fatalf("Error removing search trigger: %v", err)
+			}
+		}
+		return
+	}
+
+	// Indexes created before trigrams were used match word prefixes only,
+	// so they are replaced and rebuilt.
+	stale := ftsIndexExists() && !ftsIndexUsesTrigrams()
+	if stale {
+		if _, err := db.Exec("DROP TABLE expenses_fts"); err != nil {
+			log.Fatalf("Error replacing search index: %v", err)
+		}
+	}
+	_, err := db.Exec(fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS expenses_fts USING fts5(title, content='expenses', content_rowid='id', tokenize='%s')`, ftsTokenizer))
+	if err != nil {
+		log.Fatalf("Error creating search index: %v", err)
+	}
+
+	var existing int
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'expenses_fts_%'").Scan(&existing)
+	if err != nil {
+		log.Fatalf("Error checking search triggers: %v", err)
+	}
+	for _, trigger := range ftsTriggers {
+		_, err := db.Exec(fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s %s", trigger.name, trigger.body))
+		if err != nil {
+			log.Fatalf("Error creating search trigger: %v", err)
+		}
+	}
+	ftsAvailable = true
+
+	// The index is new, or writes happened while the triggers were missing.
+	if stale || existing < len(ftsTriggers) {
+		rebuildFTS()
+	}
+}
+
+// detectFTS enables full-text search on a read-only database when the
+// index and its triggers are already in place, without changing anything.
+func detectFTS() {
+	var enabled bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled); err != nil {
+		log.Fatalf("Error checking for FTS5 support: %v", err)
+	}
+	if !enabled {
+		return
+	}
+
+	var existing int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'expenses_fts' OR (type = 'trigger' AND name LIKE 'expenses_fts_%')").Scan(&existing)
+	if err != nil {
+		log.Fatalf("Error checking search index: %v", err)
+	}
+	ftsAvailable = existing == len(ftsTriggers)+1 && ftsIndexUsesTrigrams()
+}
+
+func ftsIndexExists() bool {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'expenses_fts'").Scan(&count); err != nil {
+		log.Fatalf("Error checking search index: %v", err)
+	}
+	return count > 0
+}
+
+// ftsIndexUsesTrigrams reports whether expenses_fts was created with
+// ftsTokenizer.
+func ftsIndexUsesTrigrams() bool {
+	var createSQL string
+	err := db.QueryRow("SELECT IFNULL(MAX(sql), '') FROM sqlite_master WHERE name = 'expenses_fts'").Scan(&createSQL)
+	if err != nil {
+		log.Fatalf("Error checking search index: %v", err)
+	}
+	return strings.Contains(createSQL, "tokenize='"+ftsTokenizer+"'")
+}
+
+func rebuildFTS() {
+	rebuildSQL := "INSERT INTO expenses_fts(expenses_fts) VALUES ('rebuild')"
+	explain(rebuildSQL)
+	if _, err := db.Exec(rebuildSQL); err != nil {
+		log.Fatalf("Error rebuilding search index: %v", err)
+	}
+}
+
+// searchCondition returns a WHERE condition matching titles that contain
+// term anywhere, ignoring case. The full-text index is used when available
+// and the term is long enough; both paths match the same titles.
+func searchCondition(term string) (string, any) {
+	if ftsAvailable && utf8.RuneCountInString(term) >= ftsMinTermLength {
+		phrase := `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		return "id IN (SELECT rowid FROM expenses_fts WHERE expenses_fts MATCH ?)", phrase
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+	return `title LIKE ? ESCAPE '\'`, "%" + escaped + "%"
+}
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the full-text search index",
+	Run: func(cmd *cobra.Command, _ []string) {
+		requireWritable(cmd)
+		if !ftsAvailable {
+			log.Fatal("Error: Full-text search is not available in this build (requires the sqlite_fts5 build tag).")
+		}
+		rebuildFTS()
+		fmt.Println("Search index rebuilt.")
+	},
+}
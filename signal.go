@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// handleInterrupts closes the database and restores the terminal when the
+// process is interrupted, since PersistentPostRun does not run in that case.
+// It is only installed when stdout is a terminal, and not for commands that
+// handle signals themselves.
+func handleInterrupts(cmd *cobra.Command) {
+	if !isTerminal(os.Stdout) || ownsSignals(cmd) {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		fmt.Print(colorReset + showCursor)
+		if db != nil {
+			db.Close()
+		}
+		code := 130
+		if sig == syscall.SIGTERM {
+			code = 143
+		}
+		os.Exit(code)
+	}()
+}
+
+// ownsSignals reports whether cmd shuts down on SIGINT and SIGTERM itself,
+// as 'ls --watch' does.
+func ownsSignals(cmd *cobra.Command) bool {
+	watching, _ := cmd.Flags().GetBool("watch")
+	return watching
+}
+
+// isTerminal reports whether f is attached to a terminal rather than a pipe
+// or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
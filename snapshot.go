@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// snapshot is a recorded set of totals, as shown by 'snapshot ls'.
+type snapshot struct {
+	ID         int                `json:"id"`
+	TakenAt    string             `json:"taken_at"`
+	Total      float64            `json:"total"`
+	Categories map[string]float64 `json:"categories"`
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Record the current grand and per-category totals",
+	Long: `Record the current grand total and per-category totals with a timestamp.
+Taken regularly, e.g. once a month, snapshots show how spending evolves;
+list them with 'monke snapshot ls'.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		requireWritable(cmd)
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Fatalf("Error starting transaction: %v", err)
+		}
+		defer tx.Rollback()
+
+		totalsSQL := "SELECT IFNULL(category, ''), SUM(amount) FROM expenses GROUP BY IFNULL(category, '')"
+		explain(totalsSQL)
+		rows, err := tx.Query(totalsSQL)
+		if err != nil {
+			log.Fatalf("Error querying totals: %v", err)
+		}
+		categoryTotalsMap := make(map[string]float64)
+		total := 0.0
+		for rows.Next() {
+			var category string
+			var amount float64
+			if err := rows.Scan(&category, &amount); err != nil {
+				log.Fatalf("Error scanning totals: %v", err)
+			}
+			categoryTotalsMap[categoryLabel(category)] += amount
+			total += amount
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			log.Fatalf("Error iterating totals: %v", err)
+		}
+
+		insertSQL := "INSERT INTO snapshots(total) VALUES (?)"
+		explain(insertSQL, total)
+		result, err := tx.Exec(insertSQL, total)
+		if err != nil {
+			log.Fatalf("Error recording snapshot: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			log.Fatalf("Error recording snapshot: %v", err)
+		}
+
+		categorySQL := "INSERT INTO snapshot_categories(snapshot_id, category, amount) VALUES (?, ?, ?)"
+		for category, amount := range categoryTotalsMap {
+			explain(categorySQL, id, category, amount)
+			if _, err := tx.Exec(categorySQL, id, category, amount); err != nil {
+				log.Fatalf("Error recording snapshot for %s: %v", category, err)
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("Error committing snapshot: %v", err)
+		}
+		fmt.Printf("Snapshot %d recorded: %s across %d categories.\n", id, formatAmount(total), len(categoryTotalsMap))
+	},
+}
+
+var snapshotLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Show how the recorded totals evolved over time",
+	Run: func(cmd *cobra.Command, _ []string) {
+		category, _ := cmd.Flags().GetString("category")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+
+		snapshots := loadSnapshots()
+		if jsonOut {
+			printJSON(snapshots)
+			return
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots yet. Record one with 'monke snapshot'.")
+			return
+		}
+
+		amountHeader := "Total"
+		if category != "" {
+			amountHeader = category
+		}
+		table := newTable([]string{"ID", "Taken", amountHeader, "Change"}, []int{
+			tablewriter.ALIGN_RIGHT,
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_RIGHT,
+			tablewriter.ALIGN_RIGHT,
+		})
+		for i, s := range snapshots {
+			amount := s.Total
+			if category != "" {
+				amount = s.Categories[category]
+			}
+			change := ""
+			if i > 0 {
+				previous := snapshots[i-1].Total
+				if category != "" {
+					previous = snapshots[i-1].Categories[category]
+				}
+				change = snapshotChange(amount - previous)
+			}
+			table.Append([]string{strconv.Itoa(s.ID), formatCreatedAt(s.TakenAt), formatAmount(amount), change})
+		}
+		table.Render()
+	},
+}
+
+// snapshotChange shows the difference from the previous snapshot, red for
+// increases in spending and green for decreases.
+func snapshotChange(diff float64) string {
+	switch {
+	case diff > 0:
+		return colorize(colorDebit, "+"+formatAmount(diff))
+	case diff < 0:
+		return colorize(colorCredit, formatAmount(diff))
+	default:
+		return formatAmount(0)
+	}
+}
+
+// loadSnapshots returns every snapshot with its category totals, oldest
+// first.
+func loadSnapshots() []snapshot {
+	query := "SELECT id, taken_at, total FROM snapshots ORDER BY id ASC"
+	explain(query)
+	rows, err := db.Query(query)
+	if err != nil {
+		log.Fatalf("Error querying snapshots: %v", err)
+	}
+	snapshots := []snapshot{}
+	byID := make(map[int]int)
+	for rows.Next() {
+		s := snapshot{Categories: make(map[string]float64)}
+		if err := rows.Scan(&s.ID, &s.TakenAt, &s.Total); err != nil {
+			log.Fatalf("Error scanning snapshot: %v", err)
+		}
+		byID[s.ID] = len(snapshots)
+		snapshots = append(snapshots, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		log.Fatalf("Error iterating snapshots: %v", err)
+	}
+
+	categorySQL := "SELECT snapshot_id, category, amount FROM snapshot_categories"
+	explain(categorySQL)
+	rows, err = db.Query(categorySQL)
+	if err != nil {
+		log.Fatalf("Error querying snapshot categories: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var category string
+		var amount float64
+		if err := rows.Scan(&id, &category, &amount); err != nil {
+			log.Fatalf("Error scanning snapshot category: %v", err)
+		}
+		if i, ok := byID[id]; ok {
+			snapshots[i].Categories[category] = amount
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatalf("Error iterating snapshot categories: %v", err)
+	}
+	return snapshots
+}
+
+func init() {
+	snapshotLsCmd.Flags().StringP("category", "c", "", "Show the totals of this category instead of the grand total")
+	snapshotLsCmd.Flags().Bool("json", false, "Print the snapshots as JSON")
+
+	snapshotCmd.AddCommand(snapshotLsCmd)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+)
+
+// histogramBuckets is the number of buckets aimed for when the bucket width
+// is chosen automatically.
+const histogramBuckets = 10
+
+// maxHistogramBuckets caps the number of buckets a --bucket width may
+// produce, as each one is printed as a line.
+const maxHistogramBuckets = 200
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show statistics about expense amounts",
+	Run: func(cmd *cobra.Command, _ []string) {
+		histogram, _ := cmd.Flags().GetBool("histogram")
+		bucketWidth, _ := cmd.Flags().GetFloat64("bucket")
+		if bucketWidth < 0 {
+			log.Fatalf("Error: Invalid bucket width '%g'. Please provide a positive number.", bucketWidth)
+		}
+
+		expenses := queryExpenses(selectExpensesSQL)
+		if len(expenses) == 0 {
+			printNoExpenses()
+			return
+		}
+
+		amounts := make([]float64, len(expenses))
+		total := 0.0
+		for i, exp := range expenses {
+			amounts[i] = exp.Amount
+			total += exp.Amount
+		}
+		slices.Sort(amounts)
+		if histogram && bucketWidth > 0 {
+			low, high := amounts[0], amounts[len(amounts)-1]
+			if _, count := histogramRange(low, high, bucketWidth); count > maxHistogramBuckets {
+				log.Fatalf("Error: Bucket width '%g' is too small for amounts from %s to %s. Please pick one that makes at most %d buckets.", bucketWidth, formatAmount(low), formatAmount(high), maxHistogramBuckets)
+			}
+		}
+
+		median := amounts[len(amounts)/2]
+		if len(amounts)%2 == 0 {
+			median = (amounts[len(amounts)/2-1] + amounts[len(amounts)/2]) / 2
+		}
+
+		fmt.Printf("Count:   %d\n", len(amounts))
+		fmt.Printf("Total:   %s\n", formatAmount(total))
+		fmt.Printf("Average: %s\n", formatAmount(total/float64(len(amounts))))
+		fmt.Printf("Median:  %s\n", formatAmount(median))
+		fmt.Printf("Min:     %s\n", formatAmount(amounts[0]))
+		fmt.Printf("Max:     %s\n", formatAmount(amounts[len(amounts)-1]))
+
+		if histogram {
+			fmt.Println()
+			renderHistogram(amounts, bucketWidth, histogramWidth())
+		}
+	},
+}
+
+// niceBucketWidth picks a round bucket width (1, 2 or 5 times a power of
+// ten) that splits the range into about histogramBuckets buckets.
+func niceBucketWidth(spread float64) float64 {
+	if spread <= 0 {
+		return 1
+	}
+	raw := spread / histogramBuckets
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, step := range []float64{1, 2, 5} {
+		if raw <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// histogramRange returns where the first bucket of width bucketWidth starts
+// and how many buckets it takes to cover low to high. The count is a float
+// so that a tiny width cannot overflow it.
+func histogramRange(low, high, bucketWidth float64) (start, count float64) {
+	start = math.Floor(low/bucketWidth) * bucketWidth
+	return start, math.Floor((high-start)/bucketWidth) + 1
+}
+
+// histogramWidth is the width of the histogram: the terminal's when stdout
+// is one, and the configured width otherwise.
+func histogramWidth() int {
+	if isTerminal(os.Stdout) {
+		if width, ok := terminalWidth(os.Stdout); ok {
+			return width
+		}
+	}
+	return cfg.Width
+}
+
+// renderHistogram prints one horizontal bar per bucket of sorted amounts,
+// scaled so that the largest bucket fills the available width.
+func renderHistogram(amounts []float64, bucketWidth float64, width int) {
+	low, high := amounts[0], amounts[len(amounts)-1]
+	if bucketWidth == 0 {
+		bucketWidth = niceBucketWidth(high - low)
+	}
+	start, count := histogramRange(low, high, bucketWidth)
+	bucketCount := int(count)
+
+	counts := make([]int, bucketCount)
+	for _, amount := range amounts {
+		i := min(int(math.Floor((amount-start)/bucketWidth)), bucketCount-1)
+		counts[i]++
+	}
+
+	labels := make([]string, bucketCount)
+	labelWidth := 0
+	for i := range counts {
+		from := start + float64(i)*bucketWidth
+		labels[i] = fmt.Sprintf("%s – %s", formatAmount(from), formatAmount(from+bucketWidth))
+		labelWidth = max(labelWidth, runewidth.StringWidth(labels[i]))
+	}
+	maxCount := slices.Max(counts)
+	countWidth := len(fmt.Sprint(maxCount))
+	barWidth := max(width-labelWidth-countWidth-4, 1)
+
+	for i, count := range counts {
+		length := int(math.Round(float64(count) / float64(maxCount) * float64(barWidth)))
+		if count > 0 {
+			length = max(length, 1)
+		}
+		bar := colorize(amountColor(float64(i), float64(bucketCount-1)), strings.Repeat(lineCharacter, length))
+		fmt.Printf("%s │ %s%s %*d\n", runewidth.FillRight(labels[i], labelWidth), bar, strings.Repeat(" ", barWidth-length), countWidth, count)
+	}
+}
+
+func init() {
+	statsCmd.Flags().Bool("histogram", false, "Show a histogram of expense amounts")
+	statsCmd.Flags().Float64("bucket", 0, "Histogram bucket width (0 picks one automatically)")
+}
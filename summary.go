@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// summaryFormats are the accepted values of 'ls --summary-format'.
+var summaryFormats = []string{"json", "csv", "table"}
+
+// printSummaryFormat prints only the totals of a listing in a
+// machine-friendly format.
+func printSummaryFormat(format string, summary summaryJSON) {
+	switch format {
+	case "json":
+		printJSON(summary)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"category", "amount", "percent"})
+		for _, cat := range summary.Categories {
+			w.Write([]string{cat.Name, strconv.FormatFloat(cat.Amount, 'f', 2, 64), strconv.FormatFloat(cat.Percent, 'f', 1, 64)})
+		}
+		w.Write([]string{"Total", strconv.FormatFloat(summary.Total, 'f', 2, 64), "100.0"})
+		w.Flush()
+		if err := w.Error(); err != nil {
+			log.Fatalf("Error writing summary: %v", err)
+		}
+	case "table":
+		table := newTable([]string{"Category", "Amount", "Percent"}, []int{
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_RIGHT,
+			tablewriter.ALIGN_RIGHT,
+		})
+		for _, cat := range summary.Categories {
+			table.Append([]string{cat.Name, formatAmount(cat.Amount), fmt.Sprintf("%.1f%%", cat.Percent)})
+		}
+		table.Render()
+		fmt.Printf("\nTotal Amount: %s\n", formatAmount(summary.Total))
+		fmt.Printf("Count: %d\n", summary.Count)
+		fmt.Printf("Average: %s\n", formatAmount(summary.Average))
+	}
+}
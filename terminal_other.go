@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package main
+
+import "os"
+
+// terminalWidth returns the number of columns of the terminal f is attached
+// to, and whether it could be read. It is not supported on this platform.
+func terminalWidth(*os.File) (int, bool) {
+	return 0, false
+}
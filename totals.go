@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// totalByKeys maps the allowed --total-by keys to the SQL expression
+// grouped on. Only these expressions are ever interpolated into a query.
+var totalByKeys = map[string]string{
+	"category": "IFNULL(NULLIF(category, ''), 'Uncategorized')",
+	"day":      "day",
+}
+
+type groupTotal struct {
+	Key    string  `json:"key"`
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// queryGroupTotals sums the amounts of the filtered expenses grouped by one
+// of the totalByKeys.
+func queryGroupTotals(key string, filter expenseFilter) []groupTotal {
+	expr, ok := totalByKeys[key]
+	if !ok {
+		log.Fatalf("Error: Invalid total-by key '%s'. Valid keys: category, day.", key)
+	}
+
+	where, args := filter.where()
+	orderBy := "key ASC"
+	if key == "category" {
+		orderBy = "SUM(amount) DESC, key ASC"
+	}
+	query := fmt.Sprintf("SELECT %s AS key, COUNT(*), SUM(amount) FROM expenses%s GROUP BY key ORDER BY %s", expr, where, orderBy)
+
+	explain(query, args...)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		log.Fatalf("Error querying totals: %v", err)
+	}
+	defer rows.Close()
+
+	totals := []groupTotal{}
+	for rows.Next() {
+		var total groupTotal
+		if err := rows.Scan(&total.Key, &total.Count, &total.Amount); err != nil {
+			log.Fatalf("Error scanning totals: %v", err)
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatalf("Error iterating totals: %v", err)
+	}
+	return totals
+}
+
+func renderGroupTotals(key string, totals []groupTotal) {
+	if len(totals) == 0 {
+		printNoExpenses()
+		return
+	}
+
+	table := newTable([]string{key, "Count", "Total"}, []int{
+		tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+	})
+	grandTotal := 0.0
+	for _, total := range totals {
+		table.Append([]string{total.Key, strconv.Itoa(total.Count), formatAmount(total.Amount)})
+		grandTotal += total.Amount
+	}
+	table.Render()
+
+	fmt.Printf("\nTotal Amount: %s\n", formatAmount(grandTotal))
+}
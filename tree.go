@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// categoryNode is one level of a category path in 'ls --tree', holding the
+// expenses filed directly under it and its sub-categories.
+type categoryNode struct {
+	name     string
+	total    float64
+	children map[string]*categoryNode
+	expenses []Expense
+}
+
+func newCategoryNode(name string) *categoryNode {
+	return &categoryNode{name: name, children: make(map[string]*categoryNode)}
+}
+
+// buildCategoryTree files expenses under their category path, split on the
+// configured separator, summing totals at every level.
+func buildCategoryTree(expenses []Expense) *categoryNode {
+	root := newCategoryNode("")
+	for _, exp := range expenses {
+		path := []string{categoryLabel(exp.Category)}
+		if cfg.CategorySeparator != "" {
+			path = strings.Split(path[0], cfg.CategorySeparator)
+		}
+		node := root
+		node.total += exp.Amount
+		for _, name := range path {
+			child, ok := node.children[name]
+			if !ok {
+				child = newCategoryNode(name)
+				node.children[name] = child
+			}
+			node = child
+			node.total += exp.Amount
+		}
+		node.expenses = append(node.expenses, exp)
+	}
+	return root
+}
+
+// sortedChildren returns the sub-categories of n, largest total first.
+func (n *categoryNode) sortedChildren() []*categoryNode {
+	children := make([]*categoryNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].total != children[j].total {
+			return children[i].total > children[j].total
+		}
+		return children[i].name < children[j].name
+	})
+	return children
+}
+
+// groupSeparators are the accepted values of 'ls --group-separator'.
+var groupSeparators = []string{"blank", "rule"}
+
+// renderExpenseTree prints expenses nested under their category path with a
+// subtotal on every category. Each top-level category keeps its ls color.
+// separator, one of groupSeparators or empty for none, is printed between
+// top-level categories; a rule is width lineCharacters in the color of the
+// category that follows.
+func renderExpenseTree(expenses []Expense, currentMonthName string, categoryColorMap map[string]string, separator string, width int) {
+	for i, top := range buildCategoryTree(expenses).sortedChildren() {
+		color := categoryColorMap[top.name]
+		if i > 0 {
+			switch separator {
+			case "blank":
+				fmt.Println()
+			case "rule":
+				fmt.Println(colorize(color, strings.Repeat(lineCharacter, width)))
+			}
+		}
+		fmt.Printf("%s %s\n", colorize(color, top.name), formatAmount(top.total))
+		printTreeNode(top, "", currentMonthName, color)
+	}
+}
+
+func printTreeNode(node *categoryNode, indent, currentMonthName, color string) {
+	children := node.sortedChildren()
+	count := len(children) + len(node.expenses)
+	i := 0
+	branch := func() (string, string) {
+		i++
+		if i == count {
+			return indent + "└── ", indent + "    "
+		}
+		return indent + "├── ", indent + "│   "
+	}
+
+	for _, child := range children {
+		prefix, childIndent := branch()
+		fmt.Printf("%s%s %s\n", prefix, colorize(color, child.name), formatAmount(child.total))
+		printTreeNode(child, childIndent, currentMonthName, color)
+	}
+	for _, exp := range node.expenses {
+		prefix, _ := branch()
+		date := fmt.Sprintf("%02d %s", exp.Day, currentMonthName)
+		if exp.Time != "" {
+			date += " " + exp.Time
+		}
+		fmt.Printf("%s%s %s (%s)\n", prefix, exp.Title, formatAmount(exp.Amount), date)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var upcomingCmd = &cobra.Command{
+	Use:   "upcoming",
+	Short: "List expenses due in the next few days",
+	Run: func(cmd *cobra.Command, _ []string) {
+		days, _ := cmd.Flags().GetInt("days")
+		if days < 0 {
+			log.Fatalf("Error: Invalid days '%d'. Please provide a positive number.", days)
+		}
+
+		now := currentTime()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+		type upcomingExpense struct {
+			Expense
+			daysUntil int
+		}
+
+		expenses := queryExpenses(selectExpensesSQL)
+		if len(expenses) == 0 {
+			printNoExpenses()
+			return
+		}
+
+		var upcoming []upcomingExpense
+		for _, exp := range expenses {
+			daysUntil := daysUntilDue(exp.Day, today)
+			if daysUntil <= days {
+				upcoming = append(upcoming, upcomingExpense{exp, daysUntil})
+			}
+		}
+
+		if len(upcoming) == 0 {
+			fmt.Printf("No expenses due in the next %d days.\n", days)
+			return
+		}
+
+		sort.SliceStable(upcoming, func(i, j int) bool {
+			return upcoming[i].daysUntil < upcoming[j].daysUntil
+		})
+
+		table := newTable([]string{"Title", "Amount", "Date", "Due", "Category", "Status"}, []int{
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_RIGHT,
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_CENTER,
+		})
+		for _, exp := range upcoming {
+			dueDate := today.AddDate(0, 0, exp.daysUntil)
+			table.Append([]string{
+				exp.Title,
+				formatAmount(exp.Amount),
+				fmt.Sprintf("%02d %s", dueDate.Day(), monthName(dueDate.Month())),
+				relativeDay(exp.daysUntil),
+				categoryLabel(exp.Category),
+				dueStatus(exp.daysUntil),
+			})
+		}
+		table.Render()
+	},
+}
+
+// daysUntilDue returns how many days from today an expense on the given day
+// of the month is next due, wrapping into next month for days already past.
+func daysUntilDue(day int, today time.Time) int {
+	diff := day - today.Day()
+	if diff < 0 {
+		daysInMonth := time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()).Day()
+		diff += daysInMonth
+	}
+	return diff
+}
+
+// relativeDay describes a day offset from today, e.g. "in 3 days" or
+// "2 days ago".
+func relativeDay(dayDiff int) string {
+	switch {
+	case dayDiff == 0:
+		return "today"
+	case dayDiff == 1:
+		return "tomorrow"
+	case dayDiff == -1:
+		return "yesterday"
+	case dayDiff < 0:
+		return fmt.Sprintf("%d days ago", -dayDiff)
+	default:
+		return fmt.Sprintf("in %d days", dayDiff)
+	}
+}
+
+func init() {
+	upcomingCmd.Flags().Int("days", 7, "Number of days ahead to look")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	clearScreen = "\033[H\033[2J"
+	hideCursor  = "\033[?25l"
+	showCursor  = "\033[?25h"
+)
+
+// watch clears the screen and calls render every interval until the process
+// is interrupted, then restores the cursor and colors.
+func watch(interval time.Duration, render func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Print(hideCursor)
+	defer fmt.Print(colorReset + showCursor)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fmt.Print(clearScreen)
+		render()
+		fmt.Printf("\nRefreshing every %s. Press Ctrl-C to exit.\n", interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
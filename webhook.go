@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// webhookEvents are the changes a webhook can be notified about.
+var webhookEvents = []string{"add", "edit", "clear"}
+
+// webhookTimeout bounds how long a command waits for the webhook, so an
+// unreachable server only delays it briefly.
+const webhookTimeout = 5 * time.Second
+
+// webhookPayload is the JSON body posted to the webhook.
+type webhookPayload struct {
+	Event   string   `json:"event"`
+	Expense *Expense `json:"expense,omitempty"`
+	Deleted int      `json:"deleted,omitempty"`
+}
+
+// notifyWebhook posts payload to the configured webhook_url when its event
+// is enabled. Failures are logged as warnings and never fail the command.
+func notifyWebhook(payload webhookPayload) {
+	if cfg.WebhookURL == "" {
+		return
+	}
+	events := cfg.WebhookEvents
+	if len(events) == 0 {
+		events = []string{"add"}
+	}
+	if !slices.Contains(events, payload.Event) {
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Warning: could not encode webhook payload: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Warning: webhook failed: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Warning: webhook failed: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Printf("Warning: webhook returned %s", resp.Status)
+	}
+}
+
+func validateWebhookURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook_url '%s': must be an http or https URL", value)
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []string) error {
+	for _, event := range events {
+		if !slices.Contains(webhookEvents, event) {
+			return fmt.Errorf("invalid webhook event '%s': must be one of %s", event, strings.Join(webhookEvents, ", "))
+		}
+	}
+	return nil
+}